@@ -0,0 +1,147 @@
+package onlineconf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// Bind fills the fields of a struct pointed to by ptr with parameter values of the module.
+// See [Subtree.Bind] for details.
+func (m *Module) Bind(ptr any) error {
+	return m.Subtree("").Bind(ptr)
+}
+
+// Bind fills the fields of a struct pointed to by ptr with parameter values of the subtree.
+//
+// The path of a field's parameter is specified by the `onlineconf` struct tag. Options can follow
+// the path after a comma:
+//
+//	required - the parameter must exist, the `default` tag is ignored
+//	json     - a nested struct is read as a single JSON value instead of a subtree
+//
+// The `default` struct tag specifies a text value used if the parameter doesn't exist.
+// Fields of non-existing parameters without a default value aren't modified, so defaults can be
+// also set in the struct before calling Bind.
+//
+// Values are decoded using the same rules as [GetErr] does, so string, int, bool, [time.Duration],
+// float64 and []string fields are parsed the same way as by [Module.GetString], [Module.GetInt],
+// [Module.GetBool], [Module.GetDuration], [Module.GetFloat] and [Module.GetStrings].
+//
+// Nested struct fields without a registered decoder are bound recursively to the subtree rooted at
+// the field's path, or to the same subtree if the field has no `onlineconf` tag. Other fields without
+// the tag, unexported fields, and fields tagged `onlineconf:"-"` are skipped.
+//
+//	type Config struct {
+//		Host    string        `onlineconf:"/host,required"`
+//		Port    int           `onlineconf:"/port" default:"5432"`
+//		Timeout time.Duration `onlineconf:"/timeout" default:"1s"`
+//		Replica struct {
+//			Hosts []string `onlineconf:"/hosts"`
+//		} `onlineconf:"/replica"`
+//	}
+//
+// Bind doesn't stop on the first error: all missing required parameters and malformed values
+// are reported in the error returned, which is joined using [errors.Join].
+func (s *Subtree) Bind(ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if !isStructPtr(rv) {
+		return fmt.Errorf("%s:%s: Bind accepts a non-nil pointer to a struct", s.mod.name, s.prefix)
+	}
+
+	var errs []error
+
+	bind(s, rv.Elem(), "", &errs)
+
+	return errors.Join(errs...)
+}
+
+func isStructPtr(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+}
+
+// bind fills the fields of the struct rv with parameter values of the source, see [Subtree.Bind].
+func bind(src Source, rv reflect.Value, fieldPrefix string, errs *[]error) {
+	typ := rv.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldName := fieldPrefix + field.Name
+		tag, hasTag := field.Tag.Lookup("onlineconf")
+
+		if tag == "-" {
+			continue
+		}
+
+		path, opts, _ := strings.Cut(tag, ",")
+		required, asJSON := false, false
+
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "required":
+				required = true
+			case "json":
+				asJSON = true
+			}
+		}
+
+		if !asJSON && isSubtreeType(field.Type) {
+			sub := src
+			if hasTag {
+				sub = src.subtree(path)
+			}
+
+			bind(sub, rv.Field(i), fieldName+".", errs)
+
+			continue
+		}
+
+		if !hasTag {
+			continue
+		}
+
+		m, p, err := src.read(path, rv.Field(i))
+
+		switch {
+		case err == nil:
+		case err != ErrNotFound:
+			*errs = append(*errs, fmt.Errorf("field %s: %w", fieldName, err))
+		case required:
+			*errs = append(*errs, fmt.Errorf("field %s: %s:%s: %w", fieldName, m.name, p, ErrNotFound))
+		default:
+			dfl, ok := field.Tag.Lookup("default")
+			if !ok {
+				break
+			}
+
+			val, err := decodeValue(field.Type, Value{Format: FormatText, Data: []byte(dfl)})
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("field %s: invalid default value %q: %w", fieldName, dfl, err))
+				break
+			}
+
+			rv.Field(i).Set(val)
+		}
+	}
+}
+
+// isSubtreeType reports whether a field of the type should be bound to a subtree.
+func isSubtreeType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	if _, ok := decoders.Load(typ); ok {
+		return false
+	}
+
+	return !reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
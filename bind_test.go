@@ -0,0 +1,100 @@
+package onlineconf
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBindConfig struct {
+	Host    string        `onlineconf:"/host,required"`
+	Port    uint16        `onlineconf:"/port" default:"5432"`
+	Timeout time.Duration `onlineconf:"/timeout" default:"1s"`
+	Debug   bool          `onlineconf:"/debug"`
+	Ratio   float64       `onlineconf:"/ratio"`
+	Hosts   []string      `onlineconf:"/hosts"`
+	IP      net.IP        `onlineconf:"/ip"`
+	Level   testLevel     `onlineconf:"/level" default:"low"`
+	Limits  struct {
+		RPS int
+	} `onlineconf:"/limits,json"`
+	Replica struct {
+		Host string `onlineconf:"/host" default:"replica.local"`
+		Port int    `onlineconf:"/port"`
+	} `onlineconf:"/replica"`
+	Inline struct {
+		Name string `onlineconf:"/name"`
+	}
+	Untagged string
+	Skipped  string `onlineconf:"-"`
+	private  string `onlineconf:"/host"` //nolint:unused
+}
+
+func TestBind(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"/db": map[string]any{
+			"host":    "db.local",
+			"timeout": "250ms",
+			"debug":   "1",
+			"ratio":   "0.5",
+			"hosts":   "a,b",
+			"ip":      "192.0.2.1",
+			"limits":  json.RawMessage(`{"RPS":100}`),
+			"replica": map[string]any{"port": 6432},
+			"name":    "main",
+		},
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	cfg := testBindConfig{Untagged: "untouched", Skipped: "untouched"}
+	cfg.Replica.Port = 1
+
+	require.NoError(t, mod.Subtree("/db").Bind(&cfg))
+
+	assert.Equal(t, "db.local", cfg.Host)
+	assert.Equal(t, uint16(5432), cfg.Port)
+	assert.Equal(t, 250*time.Millisecond, cfg.Timeout)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, net.ParseIP("192.0.2.1"), cfg.IP)
+	assert.Equal(t, testLevelLow, cfg.Level)
+	assert.Equal(t, 100, cfg.Limits.RPS)
+	assert.Equal(t, "replica.local", cfg.Replica.Host)
+	assert.Equal(t, 6432, cfg.Replica.Port)
+	assert.Equal(t, "main", cfg.Inline.Name)
+	assert.Equal(t, "untouched", cfg.Untagged)
+	assert.Equal(t, "untouched", cfg.Skipped)
+}
+
+func TestBindErrors(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"/port":         "qwerty",
+		"/level":        "unknown",
+		"/replica/port": "x",
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	var cfg testBindConfig
+
+	err = mod.Bind(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	msg := err.Error()
+	for _, field := range []string{"field Host", "field Port", "field Level", "field Replica.Port"} {
+		assert.Contains(t, msg, field)
+	}
+
+	assert.Equal(t, testBindConfig{}.Port, cfg.Port, "malformed values must not be stored")
+
+	assert.Error(t, mod.Bind(cfg), "Bind must accept pointers only")
+}
@@ -0,0 +1,114 @@
+package onlineconf
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes. Values of this type are parsed by [Get], [GetErr], [GetIfExists]
+// and [Module.Bind] using [ParseByteSize].
+type ByteSize uint64
+
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"e":   1e18,
+	"eb":  1e18,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+	"ei":  1 << 60,
+	"eib": 1 << 60,
+}
+
+// maxByteSizeFracDigits is the number of fractional digits significant for the largest unit, 2^60,
+// 10^19 still fits in uint64.
+const maxByteSizeFracDigits = 19
+
+// ParseByteSize parses a number of bytes with an optional SI (powers of 1000) or IEC (powers of 1024) suffix,
+// e.g. "1024", "10k", "10kB", "512MiB" or "1.5Gi". Suffixes are case-insensitive, "B" may be omitted,
+// and spaces between the number and the suffix are allowed. Fractional numbers are truncated to whole bytes.
+//
+// Errors are of type [*strconv.NumError].
+func ParseByteSize(s string) (uint64, error) {
+	num := strings.TrimSpace(s)
+
+	i := 0
+	for i < len(num) && (num[i] >= '0' && num[i] <= '9' || num[i] == '.') {
+		i++
+	}
+
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(num[i:]))]
+	if !ok {
+		return 0, &strconv.NumError{Func: "ParseByteSize", Num: s, Err: strconv.ErrSyntax}
+	}
+
+	intPart, fracPart, _ := strings.Cut(num[:i], ".")
+	if intPart == "" && fracPart == "" || strings.Contains(fracPart, ".") {
+		return 0, &strconv.NumError{Func: "ParseByteSize", Num: s, Err: strconv.ErrSyntax}
+	}
+
+	if len(fracPart) > maxByteSizeFracDigits { // less than a byte for any unit
+		fracPart = fracPart[:maxByteSizeFracDigits]
+	}
+
+	var whole, frac, scale uint64 = 0, 0, 1
+
+	for _, c := range intPart {
+		hi, lo := bits.Mul64(whole, 10)
+		sum, carry := bits.Add64(lo, uint64(c-'0'), 0)
+		if hi != 0 || carry != 0 {
+			return 0, &strconv.NumError{Func: "ParseByteSize", Num: s, Err: strconv.ErrRange}
+		}
+
+		whole = sum
+	}
+
+	for _, c := range fracPart { // at most 19 digits fit in uint64
+		frac, scale = frac*10+uint64(c-'0'), scale*10
+	}
+
+	hi, whole := bits.Mul64(whole, unit)
+	if hi != 0 {
+		return 0, &strconv.NumError{Func: "ParseByteSize", Num: s, Err: strconv.ErrRange}
+	}
+
+	hi, lo := bits.Mul64(frac, unit)
+	fracBytes, _ := bits.Div64(hi, lo, scale) // hi < scale, since frac < scale
+
+	size, carry := bits.Add64(whole, fracBytes, 0)
+	if carry != 0 {
+		return 0, &strconv.NumError{Func: "ParseByteSize", Num: s, Err: strconv.ErrRange}
+	}
+
+	return size, nil
+}
+
+func decodeByteSize(v Value) (ByteSize, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := ParseByteSize(str)
+
+	return ByteSize(size), err
+}
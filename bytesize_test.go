@@ -0,0 +1,96 @@
+package onlineconf
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint64
+		err  error
+	}{
+		{in: "1024", want: 1024},
+		{in: "0", want: 0},
+		{in: "10b", want: 10},
+		{in: "10k", want: 10_000},
+		{in: "10kB", want: 10_000},
+		{in: " 2 MB ", want: 2_000_000},
+		{in: "512MiB", want: 512 << 20},
+		{in: "1.5Gi", want: 3 << 29},
+		{in: "1.5GB", want: 1_500_000_000},
+		{in: ".5k", want: 500},
+		{in: "1.0000001k", want: 1000},
+		{in: "15EiB", want: 15 << 60},
+		{in: "18446744073709551615", want: math.MaxUint64},
+		{in: "18446744073709551616", err: strconv.ErrRange},
+		{in: "16EiB", err: strconv.ErrRange},
+		{in: "18446744073709551615.9k", err: strconv.ErrRange},
+		{in: "", err: strconv.ErrSyntax},
+		{in: ".", err: strconv.ErrSyntax},
+		{in: "1.2.3", err: strconv.ErrSyntax},
+		{in: "-1", err: strconv.ErrSyntax},
+		{in: "10 xb", err: strconv.ErrSyntax},
+		{in: "MiB", err: strconv.ErrSyntax},
+	} {
+		got, err := ParseByteSize(tc.in)
+		if tc.err != nil {
+			var numErr *strconv.NumError
+			require.ErrorAs(t, err, &numErr, tc.in)
+			assert.ErrorIs(t, err, tc.err, tc.in)
+
+			continue
+		}
+
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestGetWideIntegers(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"/int64":      "-9223372036854775808",
+		"/uint64":     "18446744073709551615",
+		"/size/cache": "512MiB",
+		"/size/bad":   "512 parsecs",
+		"/negative":   "-1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(math.MinInt64), mod.GetInt64("/int64", 0))
+	assert.Equal(t, uint64(math.MaxUint64), mod.GetUint64("/uint64", 0))
+	assert.Equal(t, uint64(512<<20), mod.GetByteSize("/size/cache", 0))
+
+	_, err = mod.GetUint64Err("/negative")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test:/negative: ")
+
+	_, err = mod.GetInt64Err("/uint64")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+
+	_, err = mod.GetByteSizeErr("/size/bad")
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.Equal(t, uint64(1), mod.GetByteSize("/size/bad", 1))
+
+	_, ok := mod.GetInt64IfExists("/missing")
+	assert.False(t, ok)
+	assert.Equal(t, int64(7), mod.GetInt64("/missing", 7))
+
+	sub := mod.Subtree("/size")
+	size, ok := sub.GetByteSizeIfExists("/cache")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(512<<20), size)
+
+	got, err := GetErr[ByteSize](mod, "/size/cache")
+	require.NoError(t, err)
+	assert.Equal(t, ByteSize(512<<20), got)
+
+	i64, err := GetErr[int64](mod, "/int64")
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MinInt64), i64)
+}
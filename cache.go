@@ -6,24 +6,20 @@ import (
 )
 
 // valueCache stores deserialized representations for every distinct type used when getting values of the path.
+// It's lock-free for readers: slices of cached values are never modified after they are stored,
+// and writers replace them using compare-and-swap.
 type valueCache struct {
-	sync.RWMutex
-	cache map[string][]reflect.Value // map[string]map[reflect.Type] should be inefficient when very few (usually 1) types are cached
-}
-
-func (cache *valueCache) init() {
-	cache.Lock()
-	defer cache.Unlock()
-
-	cache.cache = make(map[string][]reflect.Value)
+	cache sync.Map // map[string]*[]reflect.Value; map[string]map[reflect.Type] should be inefficient when very few (usually 1) types are cached
 }
 
 func (cache *valueCache) get(path string, val reflect.Value) bool {
-	cache.RLock()
-	defer cache.RUnlock()
+	values, ok := cache.cache.Load(path)
+	if !ok {
+		return false
+	}
 
 	typ := val.Type()
-	for _, cached := range cache.cache[path] {
+	for _, cached := range *values.(*[]reflect.Value) {
 		if cached.Type() == typ {
 			val.Set(cached)
 			return true
@@ -34,24 +30,46 @@ func (cache *valueCache) get(path string, val reflect.Value) bool {
 }
 
 func (cache *valueCache) set(path string, val reflect.Value) {
-	cache.Lock()
-	defer cache.Unlock()
+	if cache == nil { // the value isn't read from a generation, e.g. it's overridden by the environment
+		return
+	}
 
 	typ := val.Type()
-	values := cache.cache[path]
+	copied := reflect.ValueOf(val.Interface()) // store a shallow copy in the cache
 
-	for i, cached := range values {
-		if cached.Type() == typ {
-			values[i] = reflect.ValueOf(val.Interface())
+	for {
+		old, loaded := cache.cache.Load(path)
+		if !loaded {
+			if _, loaded = cache.cache.LoadOrStore(path, &[]reflect.Value{copied}); !loaded {
+				return
+			}
+
+			continue
+		}
+
+		oldValues := *old.(*[]reflect.Value)
+		values := make([]reflect.Value, 0, len(oldValues)+1)
+		replaced := false
+
+		for _, cached := range oldValues {
+			if cached.Type() == typ {
+				cached, replaced = copied, true
+			}
+
+			values = append(values, cached)
+		}
+
+		if !replaced {
+			values = append(values, copied)
+		}
+
+		if cache.cache.CompareAndSwap(path, old, &values) {
 			return
 		}
 	}
-
-	cache.cache[path] = append(values, reflect.ValueOf(val.Interface())) // store a shallow copy in the cache
 }
 
 // syncCache is a sync.Map with cache stampede protection.
-// cache invalidation isn't implemented.
 type syncCache[T any] struct {
 	m sync.Map
 }
@@ -115,3 +133,8 @@ func (sc *syncCache[T]) abort(key any, ch chan<- struct{}) {
 	sc.m.Delete(key)
 	close(ch)
 }
+
+// evict deletes the key only if it's still mapped to the value specified.
+func (sc *syncCache[T]) evict(key any, value T) {
+	sc.m.CompareAndDelete(key, value)
+}
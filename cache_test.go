@@ -45,8 +45,6 @@ func TestValueCache(t *testing.T) {
 
 	var cache valueCache
 
-	cache.init()
-
 	for _, tt := range tests {
 		wg := sync.WaitGroup{}
 
@@ -80,8 +78,6 @@ func TestCacheImmutability(t *testing.T) {
 		fromCache testCacheStruct
 	)
 
-	cache.init()
-
 	orig := testCacheStruct{a: 123, b: "test"}
 
 	cache.set("/path/to", reflect.ValueOf(orig))
@@ -0,0 +1,117 @@
+package onlineconf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ChecksumSuffix is appended to the name of a module file to get the name of its checksum sidecar file.
+//
+// The sidecar file contains a hex-encoded BLAKE2b digest of the module file optionally followed
+// by whitespace and anything else, so the output of the b2sum utility is accepted as is.
+// The digest size is determined by its length, e.g. 64 bytes for the b2sum default or 32 bytes for "b2sum -l 256".
+//
+// The sidecar file is written before the module file is replaced. If the module file is replaced first,
+// the new version is refused until the sidecar file is replaced as well.
+const ChecksumSuffix = ".b2sum"
+
+// ErrChecksum is returned if a module file doesn't match its checksum sidecar file,
+// or the sidecar file is required but is missing or malformed.
+var ErrChecksum = errors.New("onlineconf: checksum verification failed")
+
+// ChecksumMode selects whether module files are verified against their checksum sidecar files,
+// see [ChecksumSuffix] and [SetDefaultChecksumMode].
+type ChecksumMode int
+
+const (
+	ChecksumOptional ChecksumMode = iota // a module file is verified if its sidecar file exists
+	ChecksumRequired                     // a module file without a sidecar file is refused
+	ChecksumDisabled                     // sidecar files are ignored
+)
+
+func (mode ChecksumMode) String() string {
+	switch mode {
+	case ChecksumOptional:
+		return "optional"
+	case ChecksumRequired:
+		return "required"
+	case ChecksumDisabled:
+		return "disabled"
+	default:
+		return fmt.Sprintf("ChecksumMode(%d)", int(mode))
+	}
+}
+
+var defaultChecksumMode atomic.Int64
+
+// SetDefaultChecksumMode sets the mode used by modules opened after the call. The default is [ChecksumOptional].
+//
+// A version of a module file failing the verification is refused with [ErrChecksum] before it's installed,
+// the current version is kept then. The digest verified is reported by [Module.Status].
+func SetDefaultChecksumMode(mode ChecksumMode) {
+	defaultChecksumMode.Store(int64(mode))
+}
+
+// SetChecksumMode changes the checksum verification mode of the module file. It takes effect when the next version
+// of the file is loaded. Modules returned by [OpenModule] are shared, so the change affects all their users.
+// SetChecksumMode does nothing for modules created by NewModule*.
+func (m *Module) SetChecksumMode(mode ChecksumMode) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.checksumMode = mode
+}
+
+// readChecksum reads the digest from the sidecar file of the module file.
+// It returns nil if the file isn't verified according to the mode.
+func readChecksum(filename string, mode ChecksumMode) ([]byte, error) {
+	if mode == ChecksumDisabled {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filename + ChecksumSuffix)
+	if err != nil {
+		if mode == ChecksumOptional && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrChecksum, err)
+	}
+
+	fields := bytes.Fields(data)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: empty %s%s", ErrChecksum, filename, ChecksumSuffix)
+	}
+
+	digest := make([]byte, hex.DecodedLen(len(fields[0])))
+	if _, err := hex.Decode(digest, fields[0]); err != nil || len(digest) == 0 || len(digest) > blake2b.Size {
+		return nil, fmt.Errorf("%w: malformed %s%s", ErrChecksum, filename, ChecksumSuffix)
+	}
+
+	return digest, nil
+}
+
+// verifyChecksum checks that the BLAKE2b digest of the size bytes read from r equals the digest passed.
+func verifyChecksum(r io.ReaderAt, size int64, digest []byte) error {
+	h, err := blake2b.New(len(digest), nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChecksum, err)
+	}
+
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+		return fmt.Errorf("%w: %w", ErrChecksum, err)
+	}
+
+	if sum := h.Sum(nil); !bytes.Equal(sum, digest) {
+		return fmt.Errorf("%w: digest is %x instead of %x", ErrChecksum, sum, digest)
+	}
+
+	return nil
+}
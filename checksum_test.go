@@ -0,0 +1,91 @@
+package onlineconf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func writeFileAtomic(t *testing.T, fname string, data []byte) {
+	tmpFname := fname + ".tmp"
+	require.NoError(t, os.WriteFile(tmpFname, data, 0o644))
+	require.NoError(t, os.Rename(tmpFname, fname))
+}
+
+func b2sum(data []byte, name string) string {
+	sum := blake2b.Sum512(data)
+	return fmt.Sprintf("%x  %s\n", sum, name)
+}
+
+func TestChecksum(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "checksum.cdb")
+
+	v1 := buildCDB(t, map[string]string{"/key": "v1"})
+	writeFileAtomic(t, fname+ChecksumSuffix, []byte(b2sum(v1, fname)))
+	writeFileAtomic(t, fname, v1)
+
+	mod, err := OpenModule(fname)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	sum1 := blake2b.Sum512(v1)
+	assert.Equal(t, hex.EncodeToString(sum1[:]), mod.Status().Checksum)
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	// the file is replaced before its sidecar: refused until the sidecar is replaced
+	v2 := buildCDB(t, map[string]string{"/key": "v2"})
+	writeFileAtomic(t, fname, v2)
+
+	err = mod.Reload()
+	require.ErrorIs(t, err, ErrChecksum)
+	assert.Equal(t, "checksum", errorKind(err))
+	assert.Equal(t, "v1", mod.GetString("/key", ""))
+	assert.Equal(t, hex.EncodeToString(sum1[:]), mod.Status().Checksum)
+
+	sum2 := blake2b.Sum256(v2) // "b2sum -l 256"
+	writeFileAtomic(t, fname+ChecksumSuffix, []byte(hex.EncodeToString(sum2[:])))
+	waitChan(t, "/key", ch)
+	assert.Equal(t, "v2", mod.GetString("/key", ""))
+	assert.Equal(t, hex.EncodeToString(sum2[:]), mod.Status().Checksum)
+
+	// the sidecar is removed: the file isn't verified
+	require.NoError(t, os.Remove(fname+ChecksumSuffix))
+	writeCDB(t, fname, map[string]string{"/key": "v3"})
+	require.NoError(t, mod.Reload())
+	assert.Equal(t, "v3", mod.GetString("/key", ""))
+	assert.Empty(t, mod.Status().Checksum)
+
+	// the sidecar is required
+	mod.SetChecksumMode(ChecksumRequired)
+	writeCDB(t, fname, map[string]string{"/key": "v4"})
+	require.ErrorIs(t, mod.Reload(), ErrChecksum)
+
+	writeFileAtomic(t, fname+ChecksumSuffix, []byte("not a digest\n"))
+	require.ErrorIs(t, mod.Reload(), ErrChecksum)
+	assert.Equal(t, "v3", mod.GetString("/key", ""))
+
+	mod.SetChecksumMode(ChecksumDisabled)
+	require.NoError(t, mod.Reload())
+	assert.Equal(t, "v4", mod.GetString("/key", ""))
+	assert.Empty(t, mod.Status().Checksum)
+}
+
+func TestChecksumRequired(t *testing.T) {
+	SetDefaultChecksumMode(ChecksumRequired)
+	defer SetDefaultChecksumMode(ChecksumOptional)
+
+	fname := filepath.Join(t.TempDir(), "required.cdb")
+	writeCDB(t, fname, map[string]string{"/key": "value"})
+
+	_, err := OpenModule(fname)
+	require.ErrorIs(t, err, ErrChecksum)
+}
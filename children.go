@@ -0,0 +1,66 @@
+package onlineconf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoChildLists is returned if a module has no child lists since the `child_lists` OnlineConf feature
+// isn't enabled for it.
+var ErrNoChildLists = errors.New("onlineconf: child lists are not enabled")
+
+// Children returns names of the children of the path, e.g. "host" and "port" for "/db"
+// having "/db/host" and "/db/port" parameters.
+//
+// If the path exists but has no children, an empty list is returned. If neither the path nor
+// its children exist, [ErrNotFound] is returned. If the module has no child lists at all,
+// [ErrNoChildLists] is returned: the `child_lists` OnlineConf feature must be enabled for the module.
+//
+// Child lists are read from the module file as is: they aren't cached and aren't affected by environment overrides.
+// Path separators other than a slash aren't supported by this method.
+func (m *Module) Children(path string) ([]string, error) {
+	path = cleanPath(path)
+
+	gen, shard := m.pin()
+	if gen == nil {
+		return nil, ErrClosed
+	}
+
+	defer m.unpin(gen, shard)
+
+	children, err := m.genStringsRaw(gen, childListPath(path))
+	if err != nil || children != nil {
+		return children, err
+	}
+
+	if err := m.hasChildLists(gen); err != nil {
+		return nil, err
+	}
+
+	switch data, err := m.genRaw(gen, path); {
+	case err != nil:
+		return nil, err
+	case len(data) == 0:
+		return nil, ErrNotFound
+	default:
+		return []string{}, nil
+	}
+}
+
+// Children calls [Module.Children] using the subtree prefix.
+func (s *Subtree) Children(path string) ([]string, error) {
+	return s.mod.Children(s.prefix + path)
+}
+
+// hasChildLists returns [ErrNoChildLists] if the generation has no child lists.
+func (m *Module) hasChildLists(gen *generation) error {
+	root, err := m.genRaw(gen, "/")
+	switch {
+	case err != nil:
+		return err
+	case len(root) == 0:
+		return fmt.Errorf("%s: %w", m.name, ErrNoChildLists)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package onlineconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/colinmarc/cdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildren(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]string{
+		"/services/a/endpoints": "a1,a2",
+		"/services/b/endpoints": "b1",
+		"/services/b/weight":    "10",
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	children, err := mod.Children("/services")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, children)
+
+	children, err = mod.Children("/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services"}, children)
+
+	children, err = mod.Subtree("/services").Children("/b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"endpoints", "weight"}, children)
+
+	children, err = mod.Children("/services/b/weight")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+
+	_, err = mod.Children("/missing")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestNoChildLists(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "flat.cdb")
+
+	w, err := cdb.Create(fname)
+	require.NoError(t, err)
+	require.NoError(t, w.Put([]byte("/a/b"), []byte("s1")))
+	require.NoError(t, w.Close())
+
+	f, err := os.Open(fname)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	mod, err := NewModule("flat", f)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	_, err = mod.Children("/a")
+	assert.ErrorIs(t, err, ErrNoChildLists)
+
+	err = mod.Walk("/", func(string, Value) error { return nil })
+	assert.ErrorIs(t, err, ErrNoChildLists)
+
+	err = NewOverlay(mod).Walk("/", func(string, Value) error { return nil })
+	assert.ErrorIs(t, err, ErrNoChildLists)
+}
@@ -1,4 +1,11 @@
 // Command onlineconf-get gets values from the onlineconf CDB database.
+//
+// The diff subcommand compares two CDB files:
+//
+//	onlineconf-get diff old.cdb new.cdb
+//
+// It prints created (+), deleted (-) and updated (~) parameters and exits with code 1
+// if there are differences, like diff(1) does. Errors are printed to stderr with exit code 2.
 package main
 
 import (
@@ -16,16 +23,31 @@ import (
 func main() {
 	isInteractive := flag.Bool("interactive", false, "Run get onlineconf in interactive mode")
 	ocModuleName := flag.String("module", "TREE", "Onlineconf module relative name or path")
+	ocDir := flag.String("dir", "", "Directory of relative module names (default $ONLINECONF_DIR or "+onlineconf.DefaultOnlineConfPath+")")
 	asBool := flag.Bool("bool", false, "Interpret value as boolean and exit with code 0 on true and 1 on false. Only non-interactive mode")
 
 	flag.Parse()
 
+	if flag.CommandLine.Arg(0) == "diff" {
+		if flag.CommandLine.NArg() != 3 {
+			fmt.Fprintln(os.Stderr, "usage: onlineconf-get diff old.cdb new.cdb")
+			os.Exit(2)
+		}
+
+		os.Exit(diff(flag.CommandLine.Arg(1), flag.CommandLine.Arg(2)))
+	}
+
 	if *asBool && *isInteractive {
 		fmt.Fprintln(os.Stderr, "-bool option is not available in interactive mode")
 		os.Exit(2)
 	}
 
-	module, err := onlineconf.OpenModule(*ocModuleName)
+	var opts onlineconf.Options
+	if *ocDir != "" {
+		opts.Dirs = []string{*ocDir}
+	}
+
+	module, err := onlineconf.NewLoader(opts).OpenModule(*ocModuleName)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -80,3 +102,54 @@ func readOCPath(module *onlineconf.Module, path string) {
 
 	fmt.Fprintln(os.Stderr, "No such key")
 }
+
+func diff(oldName, newName string) int {
+	oldModule, err := openFile(oldName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	newModule, err := openFile(newName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	changes, err := onlineconf.DiffModules(oldModule, newModule)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, change := range changes {
+		switch change.Kind {
+		case onlineconf.Created:
+			fmt.Printf("+ %s = %s\n", change.Path, change.New)
+		case onlineconf.Deleted:
+			fmt.Printf("- %s = %s\n", change.Path, change.Old)
+		default:
+			if change.Old.Format != change.New.Format {
+				fmt.Printf("~ %s = %s -> %s (format %c -> %c)\n", change.Path, change.Old, change.New, change.Old.Format, change.New.Format)
+			} else {
+				fmt.Printf("~ %s = %s -> %s\n", change.Path, change.Old, change.New)
+			}
+		}
+	}
+
+	if len(changes) != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// openFile reads a CDB file into memory without tracking it for changes, so no file handle is kept open.
+func openFile(name string) (*onlineconf.Module, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return onlineconf.NewModuleFromBytes(name, data)
+}
@@ -0,0 +1,372 @@
+package onlineconf
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoDecoder is returned by [GetErr] if there's no way to decode a value to the type requested.
+var ErrNoDecoder = errors.New("no decoder for the type")
+
+// Source is a set of parameters values can be read from using [Get], [GetErr] and [GetIfExists].
+// It's implemented by [Module], [Subtree], [Snapshot] and [Overlay].
+type Source interface {
+	// Path returns a full path in a module.
+	Path(path string) string
+
+	// read reads a parameter value into rv using the value cache and the decoders.
+	// It returns the module and the full path the value is read from. rv isn't modified in the case of an error.
+	read(path string, rv reflect.Value) (*Module, string, error)
+
+	// modules returns the modules of the source with full paths of the parameter in them in the order of priority.
+	modules(path string) []moduleRef
+
+	// subtree returns a subtree of the source.
+	subtree(prefix string) Source
+}
+
+type moduleRef struct {
+	mod  *Module
+	path string
+}
+
+func (m *Module) read(path string, rv reflect.Value) (*Module, string, error) {
+	if m.cacheGet(path, rv) {
+		return m, path, nil
+	}
+
+	format, data, cache, err := m.lookup(path)
+	if err != nil {
+		return m, path, err
+	}
+
+	if format == 0 {
+		return m, path, ErrNotFound
+	}
+
+	val, err := decodeValue(rv.Type(), Value{Format: Format(format), Data: data})
+	if err != nil {
+		return m, path, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
+	}
+
+	rv.Set(val)
+	cache.set(path, rv)
+
+	return m, path, nil
+}
+
+func (s *Subtree) read(path string, rv reflect.Value) (*Module, string, error) {
+	return s.mod.read(s.prefix+path, rv)
+}
+
+func (m *Module) modules(path string) []moduleRef {
+	return []moduleRef{{mod: m, path: path}}
+}
+
+func (s *Subtree) modules(path string) []moduleRef {
+	return []moduleRef{{mod: s.mod, path: s.prefix + path}}
+}
+
+func (m *Module) subtree(prefix string) Source {
+	return m.Subtree(prefix)
+}
+
+func (s *Subtree) subtree(prefix string) Source {
+	return s.Subtree(prefix)
+}
+
+var decoders sync.Map // map[reflect.Type]func(Value) (any, error)
+
+func init() {
+	RegisterDecoder(decodeString)
+	RegisterDecoder(decodeInt)
+	RegisterDecoder(decodeInt64)
+	RegisterDecoder(decodeUint64)
+	RegisterDecoder(decodeByteSize)
+	RegisterDecoder(decodeBool)
+	RegisterDecoder(decodeDuration)
+	RegisterDecoder(decodeFloat)
+	RegisterDecoder(decodeStrings)
+}
+
+// RegisterDecoder registers a function used by [Get], [GetErr] and [GetIfExists] to decode values of type T.
+// A decoder registered earlier for the same type is replaced.
+//
+// Decoders for string, int, int64, uint64, bool, [time.Duration], float64 and []string are registered by default
+// and use the same parsing rules as the corresponding Module.GetXXX methods. [ByteSize] values are parsed
+// by the decoder registered by default like [Module.GetByteSize] does.
+//
+// Values of types without a registered decoder are decoded using [json.Unmarshal] if the value format
+// is [FormatJSON]. Text values ([FormatText]) are decoded using the UnmarshalText method if the pointer
+// to the type implements [encoding.TextUnmarshaler] (e.g., [net.IP]), or parsed using the [strconv]
+// package if the underlying type is a string, a bool, an integer or a float type.
+//
+// Decoders should be registered during initialization, before the first value of the type is read,
+// since decoded values are cached until the configuration is updated.
+func RegisterDecoder[T any](decode func(Value) (T, error)) {
+	decoders.Store(reflect.TypeFor[T](), func(v Value) (any, error) {
+		return decode(v)
+	})
+}
+
+func decodeValue(typ reflect.Type, v Value) (reflect.Value, error) {
+	if decode, ok := decoders.Load(typ); ok {
+		val, err := decode.(func(Value) (any, error))(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		if val == nil { // a nil interface value
+			return reflect.Zero(typ), nil
+		}
+
+		return reflect.ValueOf(val), nil
+	}
+
+	ptr := reflect.New(typ)
+
+	switch u, ok := ptr.Interface().(encoding.TextUnmarshaler); {
+	case v.Format == FormatJSON:
+		if err := json.Unmarshal(v.Data, ptr.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+	case ok && v.Format == FormatText:
+		if err := u.UnmarshalText(v.Data); err != nil {
+			return reflect.Value{}, err
+		}
+	case v.Format == FormatText && isScalarKind(typ.Kind()):
+		if err := parseScalar(ptr.Elem(), b2s(v.Data)); err != nil {
+			return reflect.Value{}, err
+		}
+	default:
+		return reflect.Value{}, fmt.Errorf("%w %s", ErrNoDecoder, typ)
+	}
+
+	return ptr.Elem(), nil
+}
+
+// GetErr reads a value of a named parameter from the source and decodes it to the type T.
+//
+// If no such value exists, [ErrNotFound] is returned.
+// Decoding errors are wrapped. See [RegisterDecoder] for details of decoding.
+//
+// Values decoded are cached internally until the configuration is updated. The cached value is
+// a shallow copy, so be careful with pointers/slices, since values pointed/contained are shared.
+func GetErr[T any](src Source, path string) (T, error) {
+	ret, _, _, err := getErr[T](src, path)
+	return ret, err
+}
+
+// getErr is GetErr also returning the module and the full path the value is read from.
+func getErr[T any](src Source, path string) (T, *Module, string, error) {
+	var ret T
+
+	rv := reflect.ValueOf(&ret).Elem()
+
+	m, path, err := src.read(path, rv)
+	m.countGet(metricTypeName(rv.Type()), err)
+
+	return ret, m, path, err
+}
+
+// metricTypeNames are names of types reported to [Metrics] by generic getters, the same as by typed getters.
+var metricTypeNames = map[reflect.Type]string{
+	reflect.TypeFor[string]():        "string",
+	reflect.TypeFor[int]():           "int",
+	reflect.TypeFor[int64]():         "int64",
+	reflect.TypeFor[uint64]():        "uint64",
+	reflect.TypeFor[ByteSize]():      "bytesize",
+	reflect.TypeFor[bool]():          "bool",
+	reflect.TypeFor[time.Duration](): "duration",
+	reflect.TypeFor[float64]():       "float",
+	reflect.TypeFor[[]string]():      "strings",
+}
+
+// metricTypeName returns the name of the type reported to [Metrics]. All the types not having typed getters
+// are reported as "generic" to keep the number of names bounded.
+func metricTypeName(typ reflect.Type) string {
+	if name, ok := metricTypeNames[typ]; ok {
+		return name
+	}
+
+	return "generic"
+}
+
+// GetIfExists reads a value of a named parameter from the source and decodes it to the type T.
+//
+// It returns the value and the boolean true if the parameter exists and is decoded successfully.
+// In the other case, it returns the boolean false and the zero value.
+//
+// CDB errors and decoding errors are logged.
+func GetIfExists[T any](src Source, path string) (T, bool) {
+	val, m, path, err := getErr[T](src, path)
+	if err != nil {
+		if err != ErrNotFound {
+			m.logGetError(path, reflect.TypeFor[T]().String(), err)
+		}
+
+		var zero T
+
+		return zero, false
+	}
+
+	return val, true
+}
+
+// Get reads a value of a named parameter from the source and decodes it to the type T.
+// Calls [GetIfExists] internally. The default value `dfl` is returned when
+// [GetIfExists] returns false.
+func Get[T any](src Source, path string, dfl T) T {
+	if val, ok := GetIfExists[T](src, path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+func decodeString(v Value) (string, error) {
+	if v.Format != FormatText {
+		return "", ErrFormatIsNotString
+	}
+
+	return b2s(v.Data), nil
+}
+
+func decodeInt(v Value) (int, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(str)
+}
+
+func decodeInt64(v Value) (int64, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(str, 10, 64)
+}
+
+func decodeUint64(v Value) (uint64, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(str, 10, 64)
+}
+
+func decodeBool(v Value) (bool, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return false, err
+	}
+
+	return parseBool(str), nil
+}
+
+func decodeDuration(v Value) (time.Duration, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseDuration(str)
+}
+
+func decodeFloat(v Value) (float64, error) {
+	str, err := decodeString(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(str, 64)
+}
+
+func decodeStrings(v Value) ([]string, error) {
+	switch v.Format {
+	case FormatText:
+		return splitStrings(b2s(v.Data)), nil
+	case FormatJSON:
+		var ret []string
+
+		if err := json.Unmarshal(v.Data, &ret); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("unexpected format '%c'", v.Format)
+	}
+}
+
+// splitStrings splits a comma-separated list dropping empty items.
+func splitStrings(s string) []string {
+	items := strings.Split(s, ",")
+	ret := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			ret = append(ret, trimmed)
+		}
+	}
+
+	return ret
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseScalar parses s into rv of a kind accepted by isScalarKind.
+func parseScalar(rv reflect.Value, s string) error {
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		rv.SetBool(parseBool(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("%w %s", ErrNoDecoder, rv.Type())
+	}
+
+	return nil
+}
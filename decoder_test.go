@@ -0,0 +1,114 @@
+package onlineconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel int
+
+const (
+	testLevelLow testLevel = iota + 1
+	testLevelHigh
+)
+
+var testLevelDecodes atomic.Int32
+
+func init() {
+	RegisterDecoder(func(v Value) (testLevel, error) {
+		testLevelDecodes.Add(1)
+
+		switch v.String() {
+		case "low":
+			return testLevelLow, nil
+		case "high":
+			return testLevelHigh, nil
+		default:
+			return 0, fmt.Errorf("unknown level %q", v.Data)
+		}
+	})
+
+	RegisterDecoder(func(v Value) (*url.URL, error) {
+		return url.Parse(v.String())
+	})
+}
+
+func TestGenericGet(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"/str":      "value",
+		"/int":      42,
+		"/bool":     "0",
+		"/duration": "15",
+		"/float":    1.5,
+		"/list":     "a, b,,c",
+		"/array":    []string{"x", "y"},
+		"/struct":   json.RawMessage(`{"Key0":"value0"}`),
+		"/ip":       "192.0.2.1",
+		"/url":      "https://example.com/path",
+		"/level":    "high",
+		"/bad":      "qwerty",
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "value", Get(mod, "/str", ""))
+	assert.Equal(t, 42, Get(mod, "/int", 0))
+	assert.False(t, Get(mod, "/bool", true))
+	assert.Equal(t, 15*time.Second, Get(mod, "/duration", time.Duration(0)))
+	assert.Equal(t, 1.5, Get(mod, "/float", 0.0))
+	assert.Equal(t, []string{"a", "b", "c"}, Get[[]string](mod, "/list", nil))
+	assert.Equal(t, []string{"x", "y"}, Get[[]string](mod, "/array", nil))
+	assert.Equal(t, testStruct{Key0: "value0"}, Get(mod, "/struct", testStruct{}))
+	assert.Equal(t, net.ParseIP("192.0.2.1"), Get[net.IP](mod, "/ip", nil))
+	assert.Equal(t, "example.com", Get[*url.URL](mod, "/url", nil).Host)
+	assert.Equal(t, testLevelHigh, Get(mod, "/level", testLevelLow))
+	assert.Equal(t, 7, Get(mod, "/not-exists", 7))
+	assert.Equal(t, 7, Get(mod, "/bad", 7))
+
+	_, err = GetErr[int](mod, "/not-exists")
+	assert.Equal(t, ErrNotFound, err, "ErrNotFound must be returned unwrapped")
+
+	_, err = GetErr[int](mod, "/struct")
+	assert.ErrorIs(t, err, ErrFormatIsNotString)
+
+	_, err = GetErr[struct{ A int }](mod, "/str")
+	assert.ErrorIs(t, err, ErrNoDecoder)
+
+	_, ok := GetIfExists[testLevel](mod, "/bad")
+	assert.False(t, ok)
+
+	sub := mod.Subtree("/")
+	val, ok := GetIfExists[int](sub, "/int")
+	assert.True(t, ok)
+	assert.Equal(t, 42, val)
+}
+
+func TestGenericGetCache(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]string{"/level": "low"})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	before := testLevelDecodes.Load()
+
+	for range 3 {
+		level, err := GetErr[testLevel](mod, "/level")
+		require.NoError(t, err)
+		assert.Equal(t, testLevelLow, level)
+	}
+
+	assert.Equal(t, before+1, testLevelDecodes.Load(), "decoded values must be cached")
+
+	_, err = GetErr[testLevel](mod.Subtree("/missing"), "/level")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
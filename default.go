@@ -0,0 +1,279 @@
+package onlineconf
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/colinmarc/cdb"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+)
+
+// DefaultModuleName is the name of the module read by package-level getters like [GetString].
+const DefaultModuleName = "TREE"
+
+var (
+	defaultModule atomic.Pointer[Module] // set by SetDefaultModule
+	modules       sync.Map               // modules returned by GetModule by names
+	modulesMutex  sync.Mutex             // serializes opening of modules by GetModule
+)
+
+// MustOpenModule is like [OpenModule] but panics if the module can't be opened.
+// It simplifies initialization of global variables. Use [GetModule] if a missing file isn't fatal.
+func MustOpenModule(name string) *Module {
+	mod, err := OpenModule(name)
+	if err != nil {
+		panic(fmt.Sprintf("onlineconf: MustOpenModule(%s): %v", name, err))
+	}
+
+	return mod
+}
+
+// GetModule returns the module opened by [OpenModule] on the first call with the name.
+// The module is shared by all the callers. If it's closed, the next call opens it again.
+//
+// GetModule never fails. If the module file can't be opened, the error is logged and passed
+// to [OnError] callbacks, and a module without parameters is returned, so its getters return default values.
+// The file is tracked for changes and is loaded as soon as it's created. Until then OpenModule fails,
+// and after that it returns the same module.
+func GetModule(name string) *Module {
+	if mod, ok := modules.Load(name); ok && mod.(*Module).gen.Load() != nil { // the generation is nil if it's closed
+		return mod.(*Module)
+	}
+
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+
+	if mod, ok := modules.Load(name); ok && mod.(*Module).gen.Load() != nil {
+		return mod.(*Module)
+	}
+
+	mod := defaultLoader().getModule(name)
+	modules.Store(name, mod)
+
+	return mod
+}
+
+// reportOpenError logs the error, records it in the module status and calls the package-level error callbacks.
+func (m *Module) reportOpenError(err error) {
+	m.logError("onlineconf: can't open module", err)
+
+	m.mutex.Lock()
+	m.lastError, m.lastErrorAt = err, time.Now()
+	m.mutex.Unlock()
+
+	callErrorCallbacks(m, err)
+}
+
+var emptyCDBData = sync.OnceValue(func() []byte {
+	records, err := cdbtree.Flatten(map[string]string{})
+	if err != nil {
+		panic(err)
+	}
+
+	data, err := cdbtree.Build(records)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+})
+
+// emptyCDB returns a CDB containing the root node only.
+func emptyCDB() *cdb.CDB {
+	db, err := cdb.New(bytes.NewReader(emptyCDBData()), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+// SetDefaultModule replaces the module read by package-level getters, e.g. with a module
+// created by [NewModuleFromMap] in tests. Passing nil restores the default, [GetModule] of [DefaultModuleName].
+// Channels subscribed by package-level functions remain subscribed to the module replaced.
+func SetDefaultModule(m *Module) {
+	defaultModule.Store(m)
+}
+
+// DefaultModule returns the module read by package-level getters, see [SetDefaultModule].
+// It can be passed to generic getters, e.g. Get[int64](DefaultModule(), path, dfl).
+func DefaultModule() *Module {
+	if m := defaultModule.Load(); m != nil {
+		return m
+	}
+
+	return GetModule(DefaultModuleName)
+}
+
+// GetSubtree calls [Module.Subtree] of the default module.
+func GetSubtree(prefix string) *Subtree {
+	return DefaultModule().Subtree(prefix)
+}
+
+// GetStringErr calls [Module.GetStringErr] of the default module.
+func GetStringErr(path string) (string, error) {
+	return DefaultModule().GetStringErr(path)
+}
+
+// GetStringIfExists calls [Module.GetStringIfExists] of the default module.
+func GetStringIfExists(path string) (string, bool) {
+	return DefaultModule().GetStringIfExists(path)
+}
+
+// GetString calls [Module.GetString] of the default module.
+func GetString(path string, dfl string) string {
+	return DefaultModule().GetString(path, dfl)
+}
+
+// GetIntErr calls [Module.GetIntErr] of the default module.
+func GetIntErr(path string) (int, error) {
+	return DefaultModule().GetIntErr(path)
+}
+
+// GetIntIfExists calls [Module.GetIntIfExists] of the default module.
+func GetIntIfExists(path string) (int, bool) {
+	return DefaultModule().GetIntIfExists(path)
+}
+
+// GetInt calls [Module.GetInt] of the default module.
+func GetInt(path string, dfl int) int {
+	return DefaultModule().GetInt(path, dfl)
+}
+
+// GetBoolErr calls [Module.GetBoolErr] of the default module.
+func GetBoolErr(path string) (bool, error) {
+	return DefaultModule().GetBoolErr(path)
+}
+
+// GetBoolIfExists calls [Module.GetBoolIfExists] of the default module.
+func GetBoolIfExists(path string) (bool, bool) {
+	return DefaultModule().GetBoolIfExists(path)
+}
+
+// GetBool calls [Module.GetBool] of the default module.
+func GetBool(path string, dfl bool) bool {
+	return DefaultModule().GetBool(path, dfl)
+}
+
+// GetDurationErr calls [Module.GetDurationErr] of the default module.
+func GetDurationErr(path string) (time.Duration, error) {
+	return DefaultModule().GetDurationErr(path)
+}
+
+// GetDurationIfExists calls [Module.GetDurationIfExists] of the default module.
+func GetDurationIfExists(path string) (time.Duration, bool) {
+	return DefaultModule().GetDurationIfExists(path)
+}
+
+// GetDuration calls [Module.GetDuration] of the default module.
+func GetDuration(path string, dfl time.Duration) time.Duration {
+	return DefaultModule().GetDuration(path, dfl)
+}
+
+// GetFloatErr calls [Module.GetFloatErr] of the default module.
+func GetFloatErr(path string) (float64, error) {
+	return DefaultModule().GetFloatErr(path)
+}
+
+// GetFloatIfExists calls [Module.GetFloatIfExists] of the default module.
+func GetFloatIfExists(path string) (float64, bool) {
+	return DefaultModule().GetFloatIfExists(path)
+}
+
+// GetFloat calls [Module.GetFloat] of the default module.
+func GetFloat(path string, dfl float64) float64 {
+	return DefaultModule().GetFloat(path, dfl)
+}
+
+// GetInt64Err calls [Module.GetInt64Err] of the default module.
+func GetInt64Err(path string) (int64, error) {
+	return DefaultModule().GetInt64Err(path)
+}
+
+// GetInt64IfExists calls [Module.GetInt64IfExists] of the default module.
+func GetInt64IfExists(path string) (int64, bool) {
+	return DefaultModule().GetInt64IfExists(path)
+}
+
+// GetInt64 calls [Module.GetInt64] of the default module.
+func GetInt64(path string, dfl int64) int64 {
+	return DefaultModule().GetInt64(path, dfl)
+}
+
+// GetUint64Err calls [Module.GetUint64Err] of the default module.
+func GetUint64Err(path string) (uint64, error) {
+	return DefaultModule().GetUint64Err(path)
+}
+
+// GetUint64IfExists calls [Module.GetUint64IfExists] of the default module.
+func GetUint64IfExists(path string) (uint64, bool) {
+	return DefaultModule().GetUint64IfExists(path)
+}
+
+// GetUint64 calls [Module.GetUint64] of the default module.
+func GetUint64(path string, dfl uint64) uint64 {
+	return DefaultModule().GetUint64(path, dfl)
+}
+
+// GetByteSizeErr calls [Module.GetByteSizeErr] of the default module.
+func GetByteSizeErr(path string) (uint64, error) {
+	return DefaultModule().GetByteSizeErr(path)
+}
+
+// GetByteSizeIfExists calls [Module.GetByteSizeIfExists] of the default module.
+func GetByteSizeIfExists(path string) (uint64, bool) {
+	return DefaultModule().GetByteSizeIfExists(path)
+}
+
+// GetByteSize calls [Module.GetByteSize] of the default module.
+func GetByteSize(path string, dfl uint64) uint64 {
+	return DefaultModule().GetByteSize(path, dfl)
+}
+
+// GetStringsErr calls [Module.GetStringsErr] of the default module.
+func GetStringsErr(path string, dfl []string) ([]string, error) {
+	return DefaultModule().GetStringsErr(path, dfl)
+}
+
+// GetStrings calls [Module.GetStrings] of the default module.
+func GetStrings(path string, dfl []string) []string {
+	return DefaultModule().GetStrings(path, dfl)
+}
+
+// GetStruct calls [Module.GetStruct] of the default module.
+func GetStruct(path string, valuePtr any) (bool, error) {
+	return DefaultModule().GetStruct(path, valuePtr)
+}
+
+// Subscribe calls [Module.Subscribe] of the default module.
+func Subscribe(path string) (chan struct{}, error) {
+	return DefaultModule().Subscribe(path)
+}
+
+// SubscribeChan calls [Module.SubscribeChan] of the default module.
+func SubscribeChan(path string, ch chan<- struct{}) error {
+	return DefaultModule().SubscribeChan(path, ch)
+}
+
+// SubscribeSubtree calls [Module.SubscribeSubtree] of the default module.
+func SubscribeSubtree(path string) (chan struct{}, error) {
+	return DefaultModule().SubscribeSubtree(path)
+}
+
+// SubscribeChanSubtree calls [Module.SubscribeChanSubtree] of the default module.
+func SubscribeChanSubtree(path string, ch chan<- struct{}) error {
+	return DefaultModule().SubscribeChanSubtree(path, ch)
+}
+
+// UnsubscribeChan calls [Module.UnsubscribeChan] of the default module.
+func UnsubscribeChan(path string, ch chan<- struct{}) {
+	DefaultModule().UnsubscribeChan(path, ch)
+}
+
+// UnsubscribeChanSubtree calls [Module.UnsubscribeChanSubtree] of the default module.
+func UnsubscribeChanSubtree(path string, ch chan<- struct{}) {
+	DefaultModule().UnsubscribeChanSubtree(path, ch)
+}
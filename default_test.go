@@ -0,0 +1,83 @@
+package onlineconf
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultModule(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"/str":      "value",
+		"/int":      42,
+		"/bool":     true,
+		"/duration": "5s",
+		"/float":    1.5,
+		"/strings":  "a,b",
+		"/service":  map[string]any{"host": "localhost"},
+	})
+	require.NoError(t, err)
+
+	SetDefaultModule(mod)
+	defer SetDefaultModule(nil)
+
+	assert.Same(t, mod, DefaultModule())
+	assert.Equal(t, "value", GetString("/str", ""))
+	assert.Equal(t, 42, GetInt("/int", 0))
+	assert.True(t, GetBool("/bool", false))
+	assert.Equal(t, 5*time.Second, GetDuration("/duration", 0))
+	assert.InDelta(t, 1.5, GetFloat("/float", 0), 1e-9)
+	assert.Equal(t, []string{"a", "b"}, GetStrings("/strings", nil))
+	assert.Equal(t, "localhost", GetSubtree("/service").GetString("/host", ""))
+	assert.Equal(t, "default", GetString("/missing", "default"))
+
+	_, err = GetIntErr("/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetModule(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "pending")
+
+	var reported error
+
+	OnError(func(m *Module, err error) {
+		if m != nil && m.name == "pending.cdb" {
+			reported = err
+		}
+	})
+
+	mod := GetModule(name)
+	require.NotNil(t, mod)
+	assert.Same(t, mod, GetModule(name))
+	require.Error(t, reported, "the error must be reported")
+	assert.Equal(t, reported, mod.Status().LastError)
+	assert.Equal(t, "default", mod.GetString("/key", "default"))
+
+	_, err := OpenModule(name)
+	require.Error(t, err, "OpenModule must fail until the file is created")
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	writeCDB(t, name+".cdb", map[string]string{"/key": "value"})
+	waitChan(t, "/key", ch)
+	assert.Equal(t, "value", mod.GetString("/key", "default"))
+
+	assert.Same(t, mod, GetModule(name), "the pending module must be kept")
+
+	opened := MustOpenModule(name)
+	assert.Same(t, mod, opened, "the pending module must be shared with OpenModule")
+	require.NoError(t, opened.Close())
+	require.NoError(t, mod.Close())
+
+	reopened := GetModule(name)
+	assert.NotSame(t, mod, reopened, "the closed module must be opened again")
+	assert.Equal(t, "value", reopened.GetString("/key", ""))
+	require.NoError(t, reopened.Close())
+
+	assert.Panics(t, func() { MustOpenModule(filepath.Join(dir, "missing")) })
+}
@@ -0,0 +1,109 @@
+package onlineconf
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/colinmarc/cdb"
+)
+
+// DiffModules compares all the parameters of two modules and returns the changes
+// needed to turn the old module into the new one, ordered by path.
+// Child lists (the `child_lists` OnlineConf feature) aren't compared.
+func DiffModules(old, new *Module) ([]Change, error) {
+	oldGen, oldShard := old.pin()
+	if oldGen == nil {
+		return nil, ErrClosed
+	}
+
+	defer old.unpin(oldGen, oldShard)
+
+	newGen, newShard := new.pin()
+	if newGen == nil {
+		return nil, ErrClosed
+	}
+
+	defer new.unpin(newGen, newShard)
+
+	return diffCDB(oldGen.cdb, newGen.cdb)
+}
+
+// OnReload registers a callback called after every reload of the module file with
+// the list of changed parameters (see [DiffModules]).
+//
+// Callbacks are called sequentially after subscription notifications are sent,
+// from the goroutine reloading the module. Comparing all the parameters is
+// expensive, so the diff is computed only if there are callbacks registered.
+func (m *Module) OnReload(fn func(changes []Change)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.reloadCallbacks = append(m.reloadCallbacks, fn)
+}
+
+func diffCDB(oldCDB, newCDB *cdb.CDB) ([]Change, error) {
+	oldValues := map[string][]byte{}
+
+	iter := oldCDB.Iter()
+	for iter.Next() {
+		if key := b2s(iter.Key()); !isChildListKey(key) {
+			oldValues[key] = iter.Value()
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("cdb.Iterator.Next: %w", err)
+	}
+
+	var changes []Change
+
+	iter = newCDB.Iter()
+	for iter.Next() {
+		key := b2s(iter.Key())
+		if isChildListKey(key) {
+			continue
+		}
+
+		newValue := iter.Value()
+		oldValue, ok := oldValues[key]
+
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: key, Kind: Created, New: rawToValue(newValue)})
+		case !bytes.Equal(oldValue, newValue):
+			changes = append(changes, Change{Path: key, Kind: Updated, Old: rawToValue(oldValue), New: rawToValue(newValue)})
+		}
+
+		delete(oldValues, key)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("cdb.Iterator.Next: %w", err)
+	}
+
+	for key, oldValue := range oldValues {
+		changes = append(changes, Change{Path: key, Kind: Deleted, Old: rawToValue(oldValue)})
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return changes, nil
+}
+
+// isChildListKey reports whether the key is a key of a child list, not of a parameter.
+func isChildListKey(key string) bool {
+	return strings.HasSuffix(key, "/")
+}
+
+// rawToValue converts a raw value including the type byte to a Value.
+func rawToValue(data []byte) Value {
+	if len(data) == 0 {
+		return Value{}
+	}
+
+	return Value{Format: Format(data[0]), Data: data[1:]}
+}
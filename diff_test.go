@@ -0,0 +1,61 @@
+package onlineconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffModules(t *testing.T) {
+	oldModule, err := NewModuleFromMap("old", map[string]string{
+		"/a":     "1",
+		"/b/c":   "2",
+		"/b/d":   "3",
+		"/empty": "",
+	})
+	require.NoError(t, err)
+
+	defer oldModule.Close()
+
+	newModule, err := NewModuleFromMap("new", map[string]string{
+		"/a":     "1",
+		"/b/c":   "changed",
+		"/b/e":   "4",
+		"/empty": "",
+	})
+	require.NoError(t, err)
+
+	defer newModule.Close()
+
+	changes, err := DiffModules(oldModule, newModule)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "/b/c", Kind: Updated, Old: Value{FormatText, []byte("2")}, New: Value{FormatText, []byte("changed")}},
+		{Path: "/b/d", Kind: Deleted, Old: Value{FormatText, []byte("3")}},
+		{Path: "/b/e", Kind: Created, New: Value{FormatText, []byte("4")}},
+	}, changes)
+
+	changes, err = DiffModules(oldModule, oldModule)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestOnReload(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/key": "old", "/deleted": "value"})
+
+	reloaded := make(chan []Change, 1)
+	mod.OnReload(func(changes []Change) {
+		assert.Equal(t, "new", mod.GetString("/key", ""), "callbacks must be called after the new file is loaded")
+		reloaded <- changes
+	})
+
+	writeCDB(t, cdbName, map[string]string{"/key": "new"})
+	require.NoError(t, mod.Reload())
+
+	changes := <-reloaded
+	assert.Equal(t, []Change{
+		{Path: "/deleted", Kind: Deleted, Old: Value{FormatText, []byte("value")}},
+		{Path: "/key", Kind: Updated, Old: Value{FormatText, []byte("old")}, New: Value{FormatText, []byte("new")}},
+	}, changes)
+}
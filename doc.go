@@ -12,13 +12,44 @@
 //	                 by the second argument is returned. In the case of an error other than a
 //	                 non-existing parameter, the error message is logged, and the default value is returned.
 //
+// Messages are logged using [log/slog], repeated errors are rate-limited. See [SetLogger] and [Module.SetLogger].
+// Metrics of reads, value caches and reloads can be collected using [SetMetrics], package
+// [github.com/onlineconf/onlineconf-go/v2/onlineconfprom] exposes them in the Prometheus text format.
+//
+// Generic functions [GetErr], [GetIfExists] and [Get] follow the same convention for values of any type
+// read from a [Module] or a [Subtree]. Decoders for custom types can be registered using [RegisterDecoder].
+//
 // Despite the OnlineConf's hierarchical nature, CDB is a simple key-value store, so slash-separated
 // "subtrees" are just string prefixes. Note that using other path separators (such as a dot used
 // by some legacy projects) isn't supported by [Subtree], [Module.SubscribeSubtree] and [Module.SubscribeChanSubtree].
 // [Module] type methods doesn't parse or make hierarchical paths and have no notion of path separator, so
 // you can use it with dot-separated paths too.
 //
-// This library tracks CDB files for changes using the [fsnotify] library. A changed file is reloaded, and new
+// This library tracks CDB files for changes using the [fsnotify] library by default, or by polling
+// (see [Watcher] and [NewPollingWatcher]). A changed file is reloaded, and new
 // values become available to the application instantly. Subscribe* method family can be used to
-// receive value change notifications using go channels.
+// receive value change notifications using go channels. SubscribeEvents* method family delivers
+// [Event] values describing the changes instead of bare notifications. New versions of a file can be
+// checked before they are installed using [Module.AddValidator], a rejected version doesn't replace the current one.
+// Reload failures are logged and passed to [Module.OnError] and [OnError] callbacks,
+// and [Module.Status] reports whether a module is stuck on a stale version.
+//
+// Files are memory-mapped by default. If a file may be written in place rather than replaced by a rename,
+// load files into the heap using [SetDefaultLoadMode] with [LoadCopy] to protect the process from SIGBUS.
+// A file accompanied by a checksum sidecar file (see [ChecksumSuffix]) is verified before it's installed,
+// so a partially written file is never used.
+//
+// Values read by separate calls may come from different versions of a module file if it was
+// reloaded in between. Use [Module.Snapshot] to read several related values consistently.
+//
+// Parameters can be overridden by environment variables like OC_DB_HOST for "/db/host"
+// once enabled by [Module.SetEnvOverrides], overrides active are reported by [Module.EnvOverrides].
+//
+// Package-level functions like [GetString] read the "TREE" module returned by [GetModule],
+// which can be replaced using [SetDefaultModule], e.g. in tests.
+//
+// Modules can also be created from in-memory CDB images or plain configuration trees
+// using [NewModule], [NewModuleFromBytes] and [NewModuleFromMap], e.g. in unit tests
+// or to provide embedded default configuration. [NewModuleFromJSON] reads a tree from a JSON object,
+// e.g. a local override file. An [Overlay] combines several modules into a single read view.
 package onlineconf
@@ -0,0 +1,207 @@
+package onlineconf
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EnvOverridePrefix is the prefix of environment variables used by [EnvName].
+const EnvOverridePrefix = "OC_"
+
+// EnvName maps a parameter path to the name of the environment variable overriding it:
+// [EnvOverridePrefix] followed by the path in upper case with all characters but letters and digits
+// replaced by underscores, e.g. "/db/host" is mapped to OC_DB_HOST, and "/my-service/rps" to OC_MY_SERVICE_RPS.
+// Child lists (keys ending with a slash) are mapped to an empty name, so they can't be overridden.
+func EnvName(path string) string {
+	if isChildListKey(path) {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(EnvOverridePrefix) + len(path))
+	b.WriteString(EnvOverridePrefix)
+
+	for _, r := range strings.TrimPrefix(path, "/") {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// EnvOverride is a parameter overridden by an environment variable, see [Module.SetEnvOverrides].
+type EnvOverride struct {
+	Path  string // full path of the parameter
+	Name  string // name of the environment variable
+	Value string // value of the environment variable
+}
+
+// envOverrides is a set of overrides resolved for a version of the module file. It's replaced on every reload.
+type envOverrides struct {
+	name      func(path string) string
+	environ   map[string]string // environment variables read when the version is loaded
+	overrides sync.Map          // *EnvOverride by paths read by getters, paths not overridden aren't stored
+	logged    *sync.Map         // paths of overrides logged already, shared by the following versions
+}
+
+// newEnvOverrides reads the environment. Overrides of the previous version, if any, are resolved again,
+// so they are reported by EnvOverrides before they are read.
+func newEnvOverrides(name func(path string) string, prev *envOverrides) *envOverrides {
+	env := &envOverrides{
+		name:    name,
+		environ: make(map[string]string),
+		logged:  &sync.Map{},
+	}
+
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok && name != "" {
+			env.environ[name] = value
+		}
+	}
+
+	if prev == nil {
+		return env
+	}
+
+	env.logged = prev.logged
+
+	prev.overrides.Range(func(path, _ any) bool {
+		if ov := env.resolve(path.(string)); ov != nil {
+			env.overrides.Store(path, ov)
+		}
+
+		return true
+	})
+
+	return env
+}
+
+// resolve looks up the environment variable overriding the path. Child lists are never overridden.
+func (env *envOverrides) resolve(path string) *EnvOverride {
+	if isChildListKey(path) {
+		return nil
+	}
+
+	name := env.name(path)
+	if name == "" {
+		return nil
+	}
+
+	value, ok := env.environ[name]
+	if !ok {
+		return nil
+	}
+
+	return &EnvOverride{Path: path, Name: name, Value: value}
+}
+
+// lookup returns the override of the path or nil if the parameter isn't overridden.
+func (env *envOverrides) lookup(path string) *EnvOverride {
+	if ov, ok := env.overrides.Load(path); ok {
+		return ov.(*EnvOverride)
+	}
+
+	ov := env.resolve(path)
+	if ov == nil {
+		return nil
+	}
+
+	stored, _ := env.overrides.LoadOrStore(strings.Clone(path), ov)
+
+	return stored.(*EnvOverride)
+}
+
+// SetEnvOverrides enables overrides of parameters of the module by environment variables, e.g. for emergency
+// hotfixes or container deployments. The name function maps a full parameter path to the name
+// of the environment variable, [EnvName] is the default mapping. An empty name means the parameter
+// can't be overridden. Passing nil disables overrides, which is the default.
+//
+// If the environment variable is set, its value is returned by all the getters of the module,
+// its subtrees and snapshots instead of the value stored in the module file, even if the parameter doesn't exist there.
+// The value keeps the format of the parameter in the file, so JSON parameters are overridden by JSON values,
+// other parameters are overridden by text values. Iteration methods like [Module.All] and [Module.Walk],
+// as well as subscriptions, aren't affected.
+//
+// The environment is read when overrides are enabled and when the module file is reloaded,
+// so changes of environment variables in between aren't seen. Child lists are never overridden.
+// The name function is called by getters reading parameters not overridden, so it must be fast
+// and safe for concurrent use.
+//
+// Overrides are never silent: the first use of every override is logged with the warning level,
+// and [Module.EnvOverrides] reports the overrides active.
+func (m *Module) SetEnvOverrides(name func(path string) string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if name == nil {
+		m.env.Store(nil)
+		return
+	}
+
+	m.env.Store(newEnvOverrides(name, nil))
+}
+
+// resolveEnv reads the environment again for a new version of the module file. The caller must hold m.mutex.
+func (m *Module) resolveEnv() {
+	if env := m.env.Load(); env != nil {
+		m.env.Store(newEnvOverrides(env.name, env))
+	}
+}
+
+// EnvOverrides returns the overrides of parameters of the module by environment variables in the order of paths.
+// Overrides are reported once the parameters are read by getters, so overrides of parameters never read aren't.
+// It returns nil if overrides aren't enabled by [Module.SetEnvOverrides].
+func (m *Module) EnvOverrides() []EnvOverride {
+	env := m.env.Load()
+	if env == nil {
+		return nil
+	}
+
+	var overrides []EnvOverride
+
+	env.overrides.Range(func(_, ov any) bool {
+		overrides = append(overrides, *ov.(*EnvOverride))
+		return true
+	})
+
+	slices.SortFunc(overrides, func(a, b EnvOverride) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return overrides
+}
+
+// lookupEnv returns the value of the environment variable overriding the path with the format of the raw data
+// read from the file.
+func (m *Module) lookupEnv(env *envOverrides, path string, data []byte) (byte, []byte, bool) {
+	ov := env.lookup(path)
+	if ov == nil {
+		return 0, nil, false
+	}
+
+	if _, logged := env.logged.LoadOrStore(ov.Path, struct{}{}); !logged {
+		m.logger().LogAttrs(context.Background(), slog.LevelWarn, "onlineconf: parameter overridden by environment",
+			slog.String("module", m.name),
+			slog.String("path", ov.Path),
+			slog.String("env", ov.Name),
+		)
+	}
+
+	format := byte('s')
+	if len(data) != 0 && data[0] == 'j' {
+		format = 'j'
+	}
+
+	return format, []byte(ov.Value), true
+}
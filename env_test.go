@@ -0,0 +1,153 @@
+package onlineconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "OC_DB_HOST", EnvName("/db/host"))
+	assert.Equal(t, "OC_MY_SERVICE_RPS_LIMIT", EnvName("/my-service/rps.limit"))
+	assert.Empty(t, EnvName("/"), "child lists must not be overridable")
+	assert.Empty(t, EnvName("/db/"), "child lists must not be overridable")
+}
+
+func TestEnvOverrides(t *testing.T) {
+	mod, err := NewModuleFromMap("env", map[string]any{
+		"/db/host":   "db.example.com",
+		"/db/port":   5432,
+		"/db/limits": json.RawMessage(`{"rps":100}`),
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	var logs bytes.Buffer
+
+	mod.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	assert.Equal(t, 5432, mod.GetInt("/db/port", 0))
+	assert.Equal(t, 5432, Get(mod, "/db/port", 0), "the value must be cached")
+	assert.Nil(t, mod.EnvOverrides(), "overrides must be disabled by default")
+
+	t.Setenv("OC_DB_HOST", "localhost")
+	t.Setenv("OC_DB_PORT", "6432")
+	t.Setenv("OC_DB_LIMITS", `{"rps":1}`)
+	t.Setenv("OC_DB_USER", "app")
+
+	assert.Equal(t, "db.example.com", mod.GetString("/db/host", ""), "overrides must be opt-in")
+
+	mod.SetEnvOverrides(EnvName)
+
+	assert.Equal(t, "localhost", mod.GetString("/db/host", ""))
+	assert.Equal(t, 6432, Get(mod, "/db/port", 0), "the cached value must be overridden")
+	assert.Equal(t, "app", mod.Subtree("/db").GetString("/user", ""), "a missing parameter must be overridden")
+
+	var limits struct{ RPS int }
+	ok, err := mod.GetStruct("/db/limits", &limits)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, limits.RPS, "a JSON parameter must be overridden by a JSON value")
+
+	snap, err := mod.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", snap.GetString("/db/host", ""))
+	snap.Release()
+
+	assert.Equal(t, 1, strings.Count(logs.String(), "OC_DB_HOST"), "the override must be logged once")
+
+	assert.Equal(t, []EnvOverride{
+		{Path: "/db/host", Name: "OC_DB_HOST", Value: "localhost"},
+		{Path: "/db/limits", Name: "OC_DB_LIMITS", Value: `{"rps":1}`},
+		{Path: "/db/port", Name: "OC_DB_PORT", Value: "6432"},
+		{Path: "/db/user", Name: "OC_DB_USER", Value: "app"},
+	}, mod.EnvOverrides())
+
+	t.Setenv("DB_HOST", "custom")
+	mod.SetEnvOverrides(func(path string) string {
+		if path == "/db/host" {
+			return "DB_HOST"
+		}
+
+		return ""
+	})
+
+	assert.Equal(t, "custom", mod.GetString("/db/host", ""))
+	assert.Equal(t, 5432, mod.GetInt("/db/port", 0))
+
+	for i := range 100 {
+		mod.GetString(fmt.Sprintf("/missing/%d", i), "")
+	}
+
+	stored := 0
+	mod.env.Load().overrides.Range(func(_, _ any) bool {
+		stored++
+		return true
+	})
+	assert.Equal(t, 1, stored, "paths not overridden must not be stored")
+
+	mod.SetEnvOverrides(nil)
+	assert.Equal(t, "db.example.com", mod.GetString("/db/host", ""))
+}
+
+func TestEnvOverridesChildLists(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "TREE.cdb")
+	writeCDB(t, fname, map[string]string{"/db": "main", "/db/host": "db.example.com"})
+
+	mod, err := OpenModule(fname)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	t.Setenv("OC_DB", "replica")
+	t.Setenv("OC_DB_PORT", "6432")
+	mod.SetEnvOverrides(EnvName)
+
+	assert.Equal(t, "replica", mod.GetString("/db", ""))
+
+	children, err := mod.Children("/db")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host"}, children, "the child list must not be overridden")
+
+	var paths []string
+
+	err = mod.Walk("/", func(path string, val Value) error {
+		paths = append(paths, path+"="+val.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/db=main", "/db/host=db.example.com"}, paths)
+
+	assert.Equal(t, []EnvOverride{{Path: "/db", Name: "OC_DB", Value: "replica"}}, mod.EnvOverrides())
+
+	writeCDB(t, fname, map[string]string{"/db": "main", "/db/host": "db.example.com", "/db/port": "5432"})
+	require.NoError(t, mod.Reload())
+
+	assert.Equal(t, 6432, mod.GetInt("/db/port", 0), "overrides must be resolved for a new version of the file")
+	assert.Equal(t, []EnvOverride{
+		{Path: "/db", Name: "OC_DB", Value: "replica"},
+		{Path: "/db/port", Name: "OC_DB_PORT", Value: "6432"},
+	}, mod.EnvOverrides())
+}
+
+func TestLoaderEnvOverrides(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "TREE.cdb")
+	writeCDB(t, fname, map[string]string{"/db/host": "db.example.com"})
+
+	t.Setenv("OC_DB_HOST", "localhost")
+
+	mod, err := NewLoader(Options{EnvOverrides: EnvName}).OpenModule(fname)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "localhost", mod.GetString("/db/host", ""))
+}
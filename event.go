@@ -0,0 +1,352 @@
+package onlineconf
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ChangeKind describes how a parameter value is changed.
+type ChangeKind int
+
+// Change kinds
+const (
+	Unchanged ChangeKind = iota
+	Created
+	Updated
+	Deleted
+)
+
+// String returns a lower-case name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Unchanged:
+		return "unchanged"
+	case Created:
+		return "created"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a change of a parameter value.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  Value // the zero Value if the parameter is created or the old value is longer than 128 bytes
+	New  Value // the zero Value if the parameter is deleted
+}
+
+// Event is sent to channels subscribed using [Module.SubscribeEventsChan] and [Module.SubscribeEventsChanSubtree]
+// (or the same methods of [Subtree] and [Overlay]).
+type Event struct {
+	Change // a change of the subscribed path itself, Kind is Unchanged if only descendant values are changed
+
+	Children []Change // changes of descendant values for subtree subscriptions, ordered by path
+}
+
+// eventChanCap is a capacity of channels made by SubscribeEvents and SubscribeEventsSubtree.
+const eventChanCap = 16
+
+type eventSubscription struct {
+	channels map[chan<- Event]struct{}
+	values   map[string]subscrValue // values of the path and its descendants (for subtree subscriptions)
+}
+
+type subscrValue struct {
+	current  []byte // value including the type byte, or its blake2b-256 hash
+	isHashed bool   // determined using maxCurrValLen
+}
+
+func (m *Module) subscribeEventsChan(path string, isRecursive bool, ch chan<- Event) error {
+	path = cleanPath(path)
+	key := subscriptionKey{
+		path:        path,
+		isRecursive: isRecursive,
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer m.countSubscriptions()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	if sub, ok := m.eventSubscriptions[key]; ok {
+		sub.channels[ch] = struct{}{}
+		return nil
+	}
+
+	values, err := m.getEventValues(key)
+	if err != nil {
+		return err
+	}
+
+	if m.eventSubscriptions == nil {
+		m.eventSubscriptions = map[subscriptionKey]*eventSubscription{}
+	}
+
+	m.eventSubscriptions[key] = &eventSubscription{
+		channels: map[chan<- Event]struct{}{ch: struct{}{}},
+		values:   values,
+	}
+
+	return nil
+}
+
+// SubscribeEventsChan creates a subscription for the specified path delivering change events.
+//
+// Unlike [Module.SubscribeChan], an [Event] describing the change is sent to the channel.
+// The old value is reported only if it's not longer than 128 bytes, since longer values
+// are tracked using their hashes. If the channel is busy (over the capacity) during a notification,
+// no blocking occurs and the event is dropped, so use a buffered channel. Changes described by a dropped event
+// are never reported again: the next event describes changes made since the dropped one.
+//
+// See [Module.SubscribeChan] for other details.
+func (m *Module) SubscribeEventsChan(path string, ch chan<- Event) error {
+	return m.subscribeEventsChan(path, false, ch)
+}
+
+// SubscribeEvents makes a buffered channel and calls [Module.SubscribeEventsChan].
+func (m *Module) SubscribeEvents(path string) (chan Event, error) {
+	ch := make(chan Event, eventChanCap)
+	return ch, m.subscribeEventsChan(path, false, ch)
+}
+
+// SubscribeEventsChanSubtree creates a subscription for the specified path itself and all descending paths
+// delivering change events. Every event lists all the descendant values changed in [Event.Children].
+//
+// `child_lists` OnlineConf feature is required for subtree notifications.
+// See [Module.SubscribeEventsChan] and [Module.SubscribeChanSubtree] for other details.
+func (m *Module) SubscribeEventsChanSubtree(path string, ch chan<- Event) error {
+	return m.subscribeEventsChan(path, true, ch)
+}
+
+// SubscribeEventsSubtree makes a buffered channel and calls [Module.SubscribeEventsChanSubtree].
+func (m *Module) SubscribeEventsSubtree(path string) (chan Event, error) {
+	ch := make(chan Event, eventChanCap)
+	return ch, m.subscribeEventsChan(path, true, ch)
+}
+
+// UnsubscribeEventsChan removes the subscription made by [Module.SubscribeEventsChan] or [Module.SubscribeEvents]
+// for the path and the channel specified. The channel is closed.
+//
+// See [Module.UnsubscribeChan] for a description of closing the channel.
+func (m *Module) UnsubscribeEventsChan(path string, ch chan<- Event) {
+	m.unsubscribeEventsChan(path, false, ch)
+	safeClose(ch)
+}
+
+// UnsubscribeEventsChanSubtree removes the subscription made by [Module.SubscribeEventsChanSubtree] or
+// [Module.SubscribeEventsSubtree] for the path and the channel specified. The channel is closed.
+//
+// See [Module.UnsubscribeChan] for a description of closing the channel.
+func (m *Module) UnsubscribeEventsChanSubtree(path string, ch chan<- Event) {
+	m.unsubscribeEventsChan(path, true, ch)
+	safeClose(ch)
+}
+
+func (m *Module) unsubscribeEventsChan(path string, isRecursive bool, ch chan<- Event) {
+	path = cleanPath(path)
+	key := subscriptionKey{
+		path:        path,
+		isRecursive: isRecursive,
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer m.countSubscriptions()
+
+	sub, ok := m.eventSubscriptions[key]
+	if !ok {
+		return
+	}
+
+	delete(sub.channels, ch)
+
+	if len(sub.channels) == 0 {
+		delete(m.eventSubscriptions, key)
+	}
+}
+
+// processEventSubscriptions returns errors of all the subscriptions joined. Processing is continued after errors.
+func (m *Module) processEventSubscriptions() error {
+	// m.mutex is already write-locked since we are in reopen()
+	var errs []error
+
+	for key, sub := range m.eventSubscriptions {
+		values, err := m.getEventValues(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ev, changed := makeEvent(key, sub.values, values, m.currentValue)
+		if !changed {
+			continue
+		}
+
+		sub.values = values
+
+		for ch := range sub.channels {
+			isNotified, isDropped := notify(ch, ev)
+			if !isNotified {
+				delete(sub.channels, ch)
+			} else if isDropped {
+				m.countDropped()
+			}
+		}
+
+		if len(sub.channels) == 0 {
+			delete(m.eventSubscriptions, key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// currentValue returns the value of the path read by processEventSubscriptions.
+func (m *Module) currentValue(path string) Value {
+	data, _ := m.getRaw(path) // has just been read successfully
+	return rawToValue(data)
+}
+
+// makeEvent compares values of the subscription, value returns new values of the paths changed.
+func makeEvent(key subscriptionKey, oldValues, newValues map[string]subscrValue, value func(path string) Value) (Event, bool) {
+	ev := Event{
+		Change: Change{Path: key.path},
+	}
+
+	changed := false
+
+	paths := make([]string, 0, len(newValues))
+	for p := range newValues {
+		paths = append(paths, p)
+	}
+
+	for p := range oldValues {
+		if _, ok := newValues[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+
+	slices.Sort(paths)
+
+	for _, p := range paths {
+		oldVal, oldOK := oldValues[p]
+		newVal, newOK := newValues[p]
+
+		change := Change{Path: p}
+
+		switch {
+		case !oldOK:
+			change.Kind = Created
+		case !newOK:
+			change.Kind = Deleted
+		case oldVal.isHashed != newVal.isHashed || !bytes.Equal(oldVal.current, newVal.current):
+			change.Kind = Updated
+		default:
+			continue
+		}
+
+		if oldOK && !oldVal.isHashed {
+			change.Old = Value{Format: Format(oldVal.current[0]), Data: oldVal.current[1:]}
+		}
+
+		if newOK {
+			change.New = value(p)
+		}
+
+		changed = true
+
+		if p == key.path {
+			ev.Change = change
+		} else {
+			ev.Children = append(ev.Children, change)
+		}
+	}
+
+	return ev, changed
+}
+
+// getEventValues returns the current value of the path and, for subtree subscriptions, of all its descendants.
+// Non-existent values aren't included.
+func (m *Module) getEventValues(key subscriptionKey) (map[string]subscrValue, error) {
+	values := map[string]subscrValue{}
+
+	if !key.isRecursive {
+		return values, m.addEventValue(values, key.path)
+	}
+
+	return values, m.addEventValues(values, key.path)
+}
+
+func (m *Module) addEventValues(values map[string]subscrValue, path string) error {
+	if path != "/" { // the root "value" is a child list
+		if err := m.addEventValue(values, path); err != nil {
+			return err
+		}
+	}
+
+	children, err := m.getStringsRaw(childListPath(path))
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := m.addEventValues(values, joinChildPath(path, child)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) addEventValue(values map[string]subscrValue, path string) error {
+	data, err := m.getRaw(path)
+	if err != nil {
+		return err
+	}
+
+	if len(data) != 0 {
+		values[path] = newSubscrValue(data)
+	}
+
+	return nil
+}
+
+// newSubscrValue returns the value including the type byte to compare with its next versions.
+func newSubscrValue(data []byte) subscrValue {
+	if len(data) <= maxCurrValLen {
+		return subscrValue{current: data}
+	}
+
+	sum := blake2b.Sum256(data)
+
+	return subscrValue{current: sum[:], isHashed: true}
+}
+
+// childListPath returns a key of the child list of the path.
+func childListPath(path string) string {
+	if path == "/" {
+		return path
+	}
+
+	return path + "/"
+}
+
+// joinChildPath returns a path of the child of the parent path.
+func joinChildPath(parent, child string) string {
+	if parent == "/" {
+		return parent + child
+	}
+
+	return parent + "/" + child
+}
@@ -0,0 +1,106 @@
+package onlineconf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitEvent(t *testing.T, key string, ch <-chan Event) Event {
+	t.Helper()
+
+	tm := time.NewTimer(time.Second)
+	defer tm.Stop()
+
+	select {
+	case <-tm.C:
+		t.Fatal(key, "event timed out")
+	case ev := <-ch:
+		return ev
+	}
+
+	return Event{}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	conf := map[string]string{
+		"/test/key":         "old",
+		"/test/long":        getLongStr(9),
+		"/test/deleted":     "value",
+		"/test/subdir/key1": "val1",
+		"/test/subdir/key2": "val2",
+	}
+
+	mod, cdbName := newTestModule(t, conf)
+
+	keyCh, err := mod.SubscribeEvents("/test/key")
+	require.NoError(t, err)
+
+	longCh, err := mod.SubscribeEvents("/test/long")
+	require.NoError(t, err)
+
+	deletedCh, err := mod.Subtree("/test").SubscribeEvents("/deleted")
+	require.NoError(t, err)
+
+	createdCh, err := mod.SubscribeEvents("/test/created")
+	require.NoError(t, err)
+
+	subdirCh, err := mod.SubscribeEventsSubtree("/test/subdir")
+	require.NoError(t, err)
+
+	conf["/test/key"] = "new"
+	conf["/test/long"] = getLongStr(10)
+	conf["/test/created"] = "hello"
+	conf["/test/subdir/key1"] = "changed"
+	conf["/test/subdir/key3"] = "val3"
+	delete(conf, "/test/deleted")
+	delete(conf, "/test/subdir/key2")
+
+	writeCDB(t, cdbName, conf)
+	require.NoError(t, mod.Reload())
+
+	assert.Equal(t, Event{Change: Change{
+		Path: "/test/key",
+		Kind: Updated,
+		Old:  Value{Format: FormatText, Data: []byte("old")},
+		New:  Value{Format: FormatText, Data: []byte("new")},
+	}}, waitEvent(t, "/test/key", keyCh))
+
+	ev := waitEvent(t, "/test/long", longCh)
+	assert.Equal(t, Updated, ev.Kind)
+	assert.Equal(t, Value{}, ev.Old, "long values are hashed")
+	assert.Equal(t, getLongStr(10), ev.New.String())
+
+	ev = waitEvent(t, "/test/deleted", deletedCh)
+	assert.Equal(t, Deleted, ev.Kind)
+	assert.Equal(t, "value", ev.Old.String())
+	assert.Equal(t, Value{}, ev.New)
+
+	ev = waitEvent(t, "/test/created", createdCh)
+	assert.Equal(t, Created, ev.Kind)
+	assert.Equal(t, "hello", ev.New.String())
+
+	ev = waitEvent(t, "/test/subdir", subdirCh)
+	assert.Equal(t, Unchanged, ev.Kind)
+	assert.Equal(t, "/test/subdir", ev.Path)
+	assert.Equal(t, []Change{
+		{Path: "/test/subdir/key1", Kind: Updated, Old: Value{FormatText, []byte("val1")}, New: Value{FormatText, []byte("changed")}},
+		{Path: "/test/subdir/key2", Kind: Deleted, Old: Value{FormatText, []byte("val2")}},
+		{Path: "/test/subdir/key3", Kind: Created, New: Value{FormatText, []byte("val3")}},
+	}, ev.Children)
+
+	mod.UnsubscribeEventsChan("/test/key", keyCh)
+
+	_, ok := <-keyCh
+	assert.False(t, ok, "the channel must be closed by UnsubscribeEventsChan")
+
+	require.NoError(t, mod.Reload())
+
+	select {
+	case ev := <-subdirCh:
+		t.Fatal("unexpected event:", ev)
+	default:
+	}
+}
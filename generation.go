@@ -0,0 +1,127 @@
+package onlineconf
+
+import (
+	"math/rand/v2"
+	"os"
+	"sync/atomic"
+
+	"github.com/colinmarc/cdb"
+	"github.com/my-mail-ru/exp/mmap"
+)
+
+// readerShards is the number of reader counters of a generation. Readers increment a random one,
+// so concurrent reads rarely write to the same cache line.
+const readerShards = 32
+
+// readerCounter is a reader counter padded to a cache line.
+type readerCounter struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// generation is a loaded version of a module file with its value cache.
+//
+// It's owned by the module while it's current and by snapshots. When the last owner releases it,
+// the generation is retired: new readers back off to the current generation, and the file is unmapped
+// as soon as the readers which were reading it when it was retired are done.
+type generation struct {
+	owners   atomic.Int64
+	readers  [readerShards]readerCounter
+	retired  atomic.Bool
+	unmapped atomic.Bool
+
+	cdb         *cdb.CDB
+	mmappedFile *mmap.ReaderAt // nil for modules created by NewModule* or loaded by LoadCopy
+	fileInfo    os.FileInfo    // the file loaded, nil for modules created by NewModule*
+	checksum    []byte         // the digest verified against the checksum sidecar file, nil if not verified
+	cache       valueCache
+}
+
+// newGeneration returns a generation owned once.
+func newGeneration(cdb *cdb.CDB, mmappedFile *mmap.ReaderAt, fileInfo os.FileInfo) *generation {
+	gen := &generation{
+		cdb:         cdb,
+		mmappedFile: mmappedFile,
+		fileInfo:    fileInfo,
+	}
+
+	gen.owners.Store(1)
+
+	return gen
+}
+
+// enter registers a reader of the generation. It returns false if the generation is retired,
+// the caller must not read it then. The shard returned must be passed to exit in both cases.
+//
+// The reader counter is incremented before the retired flag is checked, and release sets the flag
+// before summing the counters, so either the reader sees the flag or release sees the reader.
+func (gen *generation) enter() (int, bool) {
+	if gen.mmappedFile == nil { // the memory is released by the garbage collector
+		return -1, true
+	}
+
+	shard := rand.IntN(readerShards)
+	gen.readers[shard].n.Add(1)
+
+	return shard, !gen.retired.Load()
+}
+
+// exit unregisters a reader and unmaps the file if the generation is retired and it was the last reader.
+func (gen *generation) exit(shard int) error {
+	if shard < 0 {
+		return nil
+	}
+
+	gen.readers[shard].n.Add(-1)
+
+	if !gen.retired.Load() {
+		return nil
+	}
+
+	return gen.unmap()
+}
+
+// tryAcquire adds an owner unless the generation is already retired. The caller must be its reader.
+func (gen *generation) tryAcquire() bool {
+	for {
+		owners := gen.owners.Load()
+		if owners == 0 {
+			return false
+		}
+
+		if gen.owners.CompareAndSwap(owners, owners+1) {
+			return true
+		}
+	}
+}
+
+// release removes an owner. The last owner retires the generation, and the file is unmapped
+// here if there are no readers or by the last reader.
+func (gen *generation) release() error {
+	if gen.owners.Add(-1) != 0 {
+		return nil
+	}
+
+	gen.retired.Store(true)
+
+	return gen.unmap()
+}
+
+// unmap unmaps the file of the retired generation if there are no readers. The file is unmapped once.
+func (gen *generation) unmap() error {
+	if gen.mmappedFile == nil {
+		return nil
+	}
+
+	for i := range gen.readers {
+		if gen.readers[i].n.Load() != 0 {
+			return nil
+		}
+	}
+
+	if !gen.unmapped.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	return gen.mmappedFile.Close()
+}
@@ -0,0 +1,245 @@
+// Package cdbtree builds OnlineConf-compatible CDB images from configuration trees.
+package cdbtree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/colinmarc/cdb"
+)
+
+// Flatten converts a tree to a map of full parameter paths to raw values (a type byte followed by data).
+//
+// The tree must be a map with string keys. Keys are joined with the parent path using [path.Join],
+// so both nested trees ({"db": {"host": "..."}}) and flat maps ({"/db/host": "..."}) are accepted.
+// Map values with string keys are treated as subtrees, other values are encoded by [Encode].
+func Flatten(tree any) (map[string][]byte, error) {
+	records := make(map[string][]byte)
+
+	if err := flatten(records, "/", reflect.ValueOf(tree)); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func flatten(records map[string][]byte, prefix string, tree reflect.Value) error {
+	if tree.Kind() != reflect.Map || tree.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cdbtree: %s: a map with string keys expected, got %s", prefix, tree.Type())
+	}
+
+	iter := tree.MapRange()
+	for iter.Next() {
+		p := path.Join(prefix, iter.Key().String())
+		val := iter.Value()
+
+		if val.Kind() == reflect.Interface && !val.IsNil() {
+			val = val.Elem()
+		}
+
+		if isSubtree(val) {
+			if err := flatten(records, p, val); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		data, err := Encode(val.Interface())
+		if err != nil {
+			return fmt.Errorf("cdbtree: %s: %w", p, err)
+		}
+
+		records[p] = data
+	}
+
+	return nil
+}
+
+func isSubtree(val reflect.Value) bool {
+	return val.IsValid() && val.Kind() == reflect.Map && val.Type().Key().Kind() == reflect.String
+}
+
+// Encode returns a raw value of a parameter:
+//
+//	nil                        - an empty string
+//	string                     - a text value ('s')
+//	bool                       - "1" or "0"
+//	integers, floats           - a text representation of the number
+//	[time.Duration]            - a text representation of the duration, e.g. "1m30s"
+//	[json.RawMessage]          - a JSON value as is ('j')
+//	everything else            - a JSON value encoded with [json.Marshal]
+func Encode(val any) ([]byte, error) {
+	switch v := val.(type) {
+	case nil:
+		return []byte{'s'}, nil
+	case string:
+		return append([]byte{'s'}, v...), nil
+	case json.RawMessage:
+		if !json.Valid(v) {
+			return nil, errors.New("invalid JSON")
+		}
+
+		return append([]byte{'j'}, v...), nil
+	case bool:
+		if v {
+			return []byte("s1"), nil
+		}
+
+		return []byte("s0"), nil
+	case time.Duration:
+		return append([]byte{'s'}, v.String()...), nil
+	}
+
+	rv := reflect.ValueOf(val)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt([]byte{'s'}, rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint([]byte{'s'}, rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat([]byte{'s'}, rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return append([]byte{'s'}, rv.String()...), nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{'j'}, data...), nil
+}
+
+// Write writes records and their child lists (the `child_lists` OnlineConf feature) to w.
+// Records are written in the order of their paths.
+// w is closed if it implements [io.Closer].
+func Write(w io.WriteSeeker, records map[string][]byte) error {
+	writer, err := cdb.NewWriter(w, nil)
+	if err != nil {
+		return fmt.Errorf("cdb.NewWriter: %w", err)
+	}
+
+	paths := make([]string, 0, len(records))
+	for p := range records {
+		paths = append(paths, p)
+	}
+
+	slices.Sort(paths)
+
+	childLists := map[string]map[string]struct{}{}
+
+	for _, p := range paths {
+		if err := writer.Put([]byte(p), records[p]); err != nil {
+			return fmt.Errorf("cdb.Put(%q): %w", p, err)
+		}
+
+		for dir := p; dir != "/" && dir != "."; {
+			item := path.Base(dir)
+			dir = path.Dir(dir)
+
+			set, ok := childLists[dir]
+			if !ok {
+				set = map[string]struct{}{}
+				childLists[dir] = set
+			}
+
+			set[item] = struct{}{}
+		}
+	}
+
+	dirs := make([]string, 0, len(childLists))
+	for dir := range childLists {
+		dirs = append(dirs, dir)
+	}
+
+	slices.Sort(dirs)
+
+	for _, dir := range dirs {
+		list := make([]string, 0, len(childLists[dir]))
+		for item := range childLists[dir] {
+			list = append(list, item)
+		}
+
+		slices.Sort(list)
+
+		data, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("error encoding child list of %s: %w", dir, err)
+		}
+
+		listPath := "/"
+		if dir != "/" {
+			listPath = dir + "/"
+		}
+
+		if err := writer.Put([]byte(listPath), append([]byte{'j'}, data...)); err != nil {
+			return fmt.Errorf("cdb.Put(%q): %w", listPath, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("cdb.Writer.Close: %w", err)
+	}
+
+	return nil
+}
+
+// Build returns a CDB image containing the records and their child lists.
+func Build(records map[string][]byte) ([]byte, error) {
+	buf := &buffer{}
+
+	if err := Write(buf, records); err != nil {
+		return nil, err
+	}
+
+	return buf.data, nil
+}
+
+// buffer is an in-memory [io.WriteSeeker].
+type buffer struct {
+	data []byte
+	pos  int
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	if end := b.pos + len(p); end > len(b.data) {
+		b.data = append(b.data, make([]byte, end-len(b.data))...)
+	}
+
+	n := copy(b.data[b.pos:], p)
+	b.pos += n
+
+	return n, nil
+}
+
+func (b *buffer) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(b.pos) + offset
+	case io.SeekEnd:
+		pos = int64(len(b.data)) + offset
+	default:
+		return 0, errors.New("cdbtree: invalid whence")
+	}
+
+	if pos < 0 {
+		return 0, errors.New("cdbtree: negative position")
+	}
+
+	b.pos = int(pos)
+
+	return pos, nil
+}
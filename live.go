@@ -0,0 +1,145 @@
+package onlineconf
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds a value decoded from a module and keeps it up to date.
+//
+// The value is rebuilt every time the parameters it's decoded from are changed and is replaced atomically,
+// so [Live.Load] always returns a consistent value. If decoding of the updated parameters fails,
+// the error is logged and the previous value is kept.
+type Live[T any] struct {
+	mod       *Module
+	value     atomic.Pointer[T]
+	load      func() (*T, error)
+	notify    chan struct{} // subscription channel
+	changes   chan struct{}
+	done      chan struct{}
+	mutex     sync.Mutex
+	callbacks []func(old, new *T)
+	close     func()
+}
+
+// NewLive creates a [Live] value bound to the subtree using [Subtree.Bind].
+// T must be a struct type. The value is rebuilt when any parameter of the subtree is changed
+// (see [Module.SubscribeSubtree]).
+//
+// Use [Module.Subtree] with the "/" prefix to bind the whole module.
+func NewLive[T any](s *Subtree) (*Live[T], error) {
+	return newLive(s, "", true, func() (*T, error) {
+		val := new(T)
+
+		if err := s.Bind(val); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+}
+
+// NewLiveStruct creates a [Live] value read from a single parameter using [Subtree.GetStruct].
+// The value is rebuilt when the parameter is changed (see [Module.Subscribe]).
+// If the parameter doesn't exist, the value is the zero value of T.
+func NewLiveStruct[T any](s *Subtree, path string) (*Live[T], error) {
+	return newLive(s, path, false, func() (*T, error) {
+		val := new(T)
+
+		if _, err := s.GetStruct(path, val); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+}
+
+func newLive[T any](s *Subtree, path string, isRecursive bool, load func() (*T, error)) (*Live[T], error) {
+	l := &Live[T]{
+		mod:     s.mod,
+		load:    load,
+		notify:  make(chan struct{}, 1),
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	// subscribe before the initial load to not miss a change
+	if isRecursive {
+		if err := s.SubscribeChanSubtree(path, l.notify); err != nil {
+			return nil, err
+		}
+
+		l.close = func() { s.UnsubscribeChanSubtree(path, l.notify) }
+	} else {
+		if err := s.SubscribeChan(path, l.notify); err != nil {
+			return nil, err
+		}
+
+		l.close = func() { s.UnsubscribeChan(path, l.notify) }
+	}
+
+	val, err := load()
+	if err != nil {
+		l.close()
+		return nil, err
+	}
+
+	l.value.Store(val)
+
+	go l.watch()
+
+	return l, nil
+}
+
+func (l *Live[T]) watch() {
+	defer close(l.done)
+
+	for range l.notify { // closed by unsubscription
+		val, err := l.load()
+		if err != nil {
+			l.mod.logError("onlineconf: live value isn't updated", err)
+			continue
+		}
+
+		old := l.value.Swap(val)
+
+		notify(l.changes, struct{}{})
+
+		l.mutex.Lock()
+		callbacks := l.callbacks
+		l.mutex.Unlock()
+
+		for _, cb := range callbacks {
+			cb(old, val)
+		}
+	}
+}
+
+// Load returns the current value. The value is shared, so it must not be modified.
+func (l *Live[T]) Load() *T {
+	return l.value.Load()
+}
+
+// Changes returns a channel with a capacity of 1 receiving a notification after every value update.
+// If the channel is busy, no blocking occurs. The channel is closed by [Live.Close].
+func (l *Live[T]) Changes() <-chan struct{} {
+	return l.changes
+}
+
+// OnChange registers a callback called with the previous and the new values after every value update.
+// Callbacks are called sequentially in the order of registration from a goroutine
+// dedicated to the [Live] value, so they shouldn't block for a long time.
+func (l *Live[T]) OnChange(fn func(old, new *T)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.callbacks = append(l.callbacks[:len(l.callbacks):len(l.callbacks)], fn) // never modify a slice being iterated
+}
+
+// Close stops updating the value. Callbacks registered with [Live.OnChange] aren't called after Close returns.
+// The last value is still available using [Live.Load].
+func (l *Live[T]) Close() {
+	l.close()
+	<-l.done
+	safeClose(l.changes)
+}
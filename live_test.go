@@ -0,0 +1,105 @@
+package onlineconf
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorLogHandler sends messages of error records to a channel, dropping them if the channel is full.
+type errorLogHandler chan string
+
+func (h errorLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelError
+}
+
+func (h errorLogHandler) Handle(_ context.Context, rec slog.Record) error {
+	select {
+	case h <- rec.Message:
+	default:
+	}
+
+	return nil
+}
+
+func (h errorLogHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h errorLogHandler) WithGroup(string) slog.Handler { return h }
+
+type testLiveConfig struct {
+	Host string        `onlineconf:"/host"`
+	TTL  time.Duration `onlineconf:"/ttl" default:"1m"`
+}
+
+func TestLive(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{
+		"/service/host": "a.local",
+		"/other":        "value",
+	})
+
+	errorLogs := make(errorLogHandler, 1)
+	mod.SetLogger(slog.New(errorLogs))
+
+	live, err := NewLive[testLiveConfig](mod.Subtree("/service"))
+	require.NoError(t, err)
+
+	defer live.Close()
+
+	assert.Equal(t, &testLiveConfig{Host: "a.local", TTL: time.Minute}, live.Load())
+
+	changes := make(chan [2]string, 1)
+	live.OnChange(func(old, new *testLiveConfig) {
+		changes <- [2]string{old.Host, new.Host}
+	})
+
+	writeCDB(t, cdbName, map[string]string{
+		"/service/host": "b.local",
+		"/service/ttl":  "5s",
+		"/other":        "value",
+	})
+	require.NoError(t, mod.Reload())
+
+	waitChan(t, "live", live.Changes())
+	assert.Equal(t, &testLiveConfig{Host: "b.local", TTL: 5 * time.Second}, live.Load())
+	assert.Equal(t, [2]string{"a.local", "b.local"}, <-changes)
+
+	writeCDB(t, cdbName, map[string]string{
+		"/service/host": "c.local",
+		"/service/ttl":  "broken",
+		"/other":        "value",
+	})
+	require.NoError(t, mod.Reload())
+
+	select {
+	case msg := <-errorLogs:
+		assert.Equal(t, "onlineconf: live value isn't updated", msg)
+	case <-time.After(time.Second):
+		t.Fatal("decode failure isn't logged")
+	}
+
+	assert.Equal(t, &testLiveConfig{Host: "b.local", TTL: 5 * time.Second}, live.Load(), "the previous value must be kept")
+
+	live.Close()
+
+	_, ok := <-live.Changes()
+	assert.False(t, ok, "Changes() channel must be closed")
+}
+
+func TestLiveStruct(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]string{"/struct": `{"Key0":"value0"}`})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	live, err := NewLiveStruct[testStruct](mod.Subtree("/"), "/missing")
+	require.NoError(t, err)
+	assert.Equal(t, &testStruct{}, live.Load())
+	live.Close()
+
+	_, err = NewLiveStruct[testStruct](mod.Subtree("/"), "/struct")
+	assert.Error(t, err, "text values can't be read by GetStruct")
+}
@@ -0,0 +1,184 @@
+package onlineconf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/colinmarc/cdb"
+	"github.com/my-mail-ru/exp/mmap"
+)
+
+var (
+	// ErrInvalidFile is returned if a module file fails the sanity check of the CDB header,
+	// e.g. it's truncated or is being written in place. The file isn't mapped then.
+	ErrInvalidFile = errors.New("onlineconf: invalid CDB file")
+
+	// ErrFileChanged is returned if a module file is modified while it's being loaded.
+	ErrFileChanged = errors.New("onlineconf: file changed while loading")
+)
+
+// LoadMode selects how module files are loaded, see [SetDefaultLoadMode].
+type LoadMode int
+
+const (
+	// LoadMmap memory-maps module files. Loading is cheap and the memory is shared between processes,
+	// but a file written in place (rather than replaced by a rename) may crash the process with SIGBUS
+	// when its mapped pages are truncated.
+	LoadMmap LoadMode = iota
+
+	// LoadCopy reads module files into the heap. A file written in place can't crash the process,
+	// at the cost of memory used by every process and a copy on every reload.
+	LoadCopy
+)
+
+func (mode LoadMode) String() string {
+	switch mode {
+	case LoadMmap:
+		return "mmap"
+	case LoadCopy:
+		return "copy"
+	default:
+		return fmt.Sprintf("LoadMode(%d)", int(mode))
+	}
+}
+
+var defaultLoadMode atomic.Int64
+
+// SetDefaultLoadMode sets the mode used by modules opened after the call. The default is [LoadMmap].
+//
+// Regardless of the mode, files failing the sanity check of the CDB header are refused with [ErrInvalidFile],
+// and files modified while loading are refused with [ErrFileChanged], the current version is kept then.
+// Modifications of a file in place are detected by the watcher, and the file is reloaded when it's consistent again.
+func SetDefaultLoadMode(mode LoadMode) {
+	defaultLoadMode.Store(int64(mode))
+}
+
+// SetLoadMode changes the mode of loading of the module file. It takes effect when the next version
+// of the file is loaded. Modules returned by [OpenModule] are shared, so the change affects all their users.
+// SetLoadMode does nothing for modules created by NewModule*.
+func (m *Module) SetLoadMode(mode LoadMode) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.loadMode = mode
+}
+
+// cdbHeaderSize is the size of the CDB header: 256 pairs of positions and lengths of hash tables.
+const cdbHeaderSize = 256 * 8
+
+// openGeneration loads the file using the modes. The file info of the generation describes the file actually loaded.
+func openGeneration(filename string, mode LoadMode, checksumMode ChecksumMode) (*generation, error) {
+	checksum, err := readChecksum(filename, checksumMode) // read first, since it's written before the file
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	if err := checkCDB(f, fileInfo.Size()); err != nil {
+		return nil, err
+	}
+
+	var (
+		reader      io.ReaderAt
+		mmappedFile *mmap.ReaderAt
+	)
+
+	switch mode {
+	case LoadCopy:
+		data := make([]byte, fileInfo.Size())
+		if _, err := f.ReadAt(data, 0); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = fmt.Errorf("%w: truncated", ErrFileChanged)
+			}
+
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		reader = bytes.NewReader(data)
+
+	default:
+		if mmappedFile, err = mmap.Open(filename); err != nil {
+			return nil, fmt.Errorf("mmap.Open: %w", err)
+		}
+
+		if n := mmappedFile.Len(); int64(n) != fileInfo.Size() { // the file is reopened by name, it may be another one
+			mmappedFile.Close()
+			return nil, fmt.Errorf("%w: %d bytes mapped instead of %d", ErrFileChanged, n, fileInfo.Size())
+		}
+
+		reader = mmappedFile
+	}
+
+	closeMapped := func() {
+		if mmappedFile != nil {
+			mmappedFile.Close()
+		}
+	}
+
+	if after, err := f.Stat(); err != nil || !isSameFile(fileInfo, after) {
+		closeMapped()
+
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+
+		return nil, ErrFileChanged
+	}
+
+	if checksum != nil {
+		if err := verifyChecksum(reader, fileInfo.Size(), checksum); err != nil {
+			closeMapped()
+			return nil, err
+		}
+	}
+
+	db, err := cdb.New(reader, nil)
+	if err != nil {
+		closeMapped()
+		return nil, fmt.Errorf("cdb.New: %w", err)
+	}
+
+	gen := newGeneration(db, mmappedFile, fileInfo)
+	gen.checksum = checksum
+
+	return gen, nil
+}
+
+// checkCDB checks that all the hash tables listed in the CDB header are within the file.
+func checkCDB(r io.ReaderAt, size int64) error {
+	if size < cdbHeaderSize {
+		return fmt.Errorf("%w: %d bytes is less than the header", ErrInvalidFile, size)
+	}
+
+	header := make([]byte, cdbHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("%w: read header: %w", ErrInvalidFile, err)
+	}
+
+	for i := range 256 {
+		pos := int64(binary.LittleEndian.Uint32(header[i*8:]))
+		length := int64(binary.LittleEndian.Uint32(header[i*8+4:]))
+
+		if pos < cdbHeaderSize || pos+length*8 > size {
+			return fmt.Errorf("%w: hash table %d (%d slots at %d) is out of %d bytes", ErrInvalidFile, i, length, pos, size)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package onlineconf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildCDB(t *testing.T, tree map[string]string) []byte {
+	records, err := cdbtree.Flatten(tree)
+	require.NoError(t, err)
+
+	data, err := cdbtree.Build(records)
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestCheckCDB(t *testing.T) {
+	dir := t.TempDir()
+	data := buildCDB(t, map[string]string{"/key": "value"})
+
+	for name, content := range map[string][]byte{
+		"empty":     nil,
+		"header":    data[:cdbHeaderSize-1],
+		"truncated": data[:len(data)-1],
+	} {
+		t.Run(name, func(t *testing.T) {
+			fname := filepath.Join(dir, name+".cdb")
+			require.NoError(t, os.WriteFile(fname, content, 0o644))
+
+			_, err := OpenModule(fname)
+			require.ErrorIs(t, err, ErrInvalidFile)
+			assert.Equal(t, "file", errorKind(err))
+		})
+	}
+}
+
+func TestLoadCopy(t *testing.T) {
+	SetDefaultLoadMode(LoadCopy)
+	defer SetDefaultLoadMode(LoadMmap)
+
+	fname := filepath.Join(t.TempDir(), "copy.cdb")
+	writeCDB(t, fname, map[string]string{"/key": "v1"})
+
+	mod, err := OpenModule(fname)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Nil(t, mod.gen.Load().mmappedFile, "the file must not be mapped")
+	assert.Equal(t, "v1", mod.GetString("/key", ""))
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	// written in place: the truncated version is refused, the complete one is loaded after the Write event
+	data := buildCDB(t, map[string]string{"/key": "v2"})
+	require.NoError(t, os.WriteFile(fname, data[:len(data)/2], 0o644))
+	require.ErrorIs(t, mod.Reload(), ErrInvalidFile)
+	assert.Equal(t, "v1", mod.GetString("/key", ""))
+
+	require.NoError(t, os.WriteFile(fname, data, 0o644))
+	waitChan(t, "/key", ch)
+	assert.Equal(t, "v2", mod.GetString("/key", ""))
+
+	mod.SetLoadMode(LoadMmap)
+	writeCDB(t, fname, map[string]string{"/key": "v3"})
+	require.NoError(t, mod.Reload())
+	assert.Equal(t, "v3", mod.GetString("/key", ""))
+	assert.NotNil(t, mod.gen.Load().mmappedFile, "the file must be mapped")
+}
+
+func TestLoadWrittenInPlace(t *testing.T) {
+	SetDefaultLoadMode(LoadCopy)
+	defer SetDefaultLoadMode(LoadMmap)
+
+	fname := filepath.Join(t.TempDir(), "inplace.cdb")
+	writeCDB(t, fname, map[string]string{"/key": "v1"})
+
+	mod, err := OpenModule(fname)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	var errs atomic.Int32
+	mod.OnError(func(error) { errs.Add(1) })
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_TRUNC, 0)
+	require.NoError(t, err)
+
+	data := buildCDB(t, map[string]string{"/key": "v2"})
+	for chunk := range slices.Chunk(data, len(data)/4+1) { // every write produces an event
+		_, err := f.Write(chunk)
+		require.NoError(t, err)
+
+		time.Sleep(writeQuietPeriod / 10)
+	}
+
+	require.NoError(t, f.Close())
+
+	waitChan(t, "/key", ch)
+	assert.Equal(t, "v2", mod.GetString("/key", ""))
+	assert.Zero(t, errs.Load(), "partially written files must not be reported")
+}
@@ -0,0 +1,261 @@
+package onlineconf
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EnvOnlineConfDir is the environment variable overriding [DefaultOnlineConfPath].
+// It may contain a list of directories separated by [os.PathListSeparator].
+const EnvOnlineConfDir = "ONLINECONF_DIR"
+
+// Options configure a [Loader]. Zero values select defaults.
+type Options struct {
+	// Dirs are searched in order for modules specified by names without a path separator.
+	// The first directory is used if the module file doesn't exist in any of them.
+	// The default is the list in the ONLINECONF_DIR environment variable or [DefaultOnlineConfPath].
+	Dirs []string
+
+	// Ext is appended to names without an extension. The default is [DefaultOnlineConfExt].
+	Ext string
+
+	// Watcher tracks module files for changes. The default is the one set by [SetDefaultWatcher]
+	// at the moment a module is opened.
+	Watcher Watcher
+
+	// Logger is set as the logger of modules opened (see [Module.SetLogger]).
+	// The default is the package logger set by [SetLogger].
+	Logger *slog.Logger
+
+	// EnvOverrides enables overrides of parameters of modules opened by environment variables,
+	// see [Module.SetEnvOverrides]. Use [EnvName] for the default mapping. Overrides are disabled by default.
+	EnvOverrides func(path string) string
+}
+
+// Loader opens modules using [Options].
+//
+// Modules are cached by names per loader and by file paths globally, so a file opened by several loaders
+// is represented by a single module with the options of the loader opened it first.
+type Loader struct {
+	opts Options
+}
+
+// NewLoader returns a loader using the options. Environment variables are read by NewLoader.
+func NewLoader(opts Options) *Loader {
+	if len(opts.Dirs) == 0 {
+		opts.Dirs = filepath.SplitList(os.Getenv(EnvOnlineConfDir))
+	}
+
+	if len(opts.Dirs) == 0 {
+		opts.Dirs = []string{DefaultOnlineConfPath}
+	}
+
+	if opts.Ext == "" {
+		opts.Ext = DefaultOnlineConfExt
+	}
+
+	return &Loader{opts: opts}
+}
+
+// defaultLoader is used by OpenModule, it's created on the first use.
+var defaultLoader = sync.OnceValue(func() *Loader {
+	return NewLoader(Options{})
+})
+
+// modCache stores modules by nameKey and by absolute file paths with symlinks unresolved: a module follows
+// its path when symlinks are swapped, so the path rather than the file it points to identifies the module.
+var modCache syncCache[*Module]
+
+type nameKey struct {
+	loader *Loader
+	name   string
+}
+
+// OpenModule opens a CDB configuration database, see the package-level [OpenModule] for details.
+func (l *Loader) OpenModule(name string) (*Module, error) {
+	key := nameKey{loader: l, name: name}
+
+	for {
+		cached, inProgressByName, ok := modCache.load(key)
+		if !ok {
+			return l.openModule(key, inProgressByName, false)
+		}
+
+		if cached.acquire() {
+			return cached.checkLoaded()
+		}
+
+		modCache.evict(key, cached) // the module is being closed concurrently, don't wait for Close to evict it
+	}
+}
+
+// getModule is like OpenModule, but returns a module without parameters loading the module file
+// when it's created instead of failing if the file can't be opened. Errors are reported using reportOpenError.
+func (l *Loader) getModule(name string) *Module {
+	key := nameKey{loader: l, name: name}
+
+	for {
+		cached, inProgressByName, ok := modCache.load(key)
+		if !ok {
+			module, err := l.openModule(key, inProgressByName, true)
+			if module == nil { // the path can't be resolved, so there's no file to track
+				module = &Module{name: name, refs: 1}
+				module.install(newGeneration(emptyCDB(), nil, nil))
+			}
+
+			if err != nil {
+				module.reportOpenError(err)
+			}
+
+			return module
+		}
+
+		if cached.acquire() {
+			return cached
+		}
+
+		modCache.evict(key, cached)
+	}
+}
+
+// checkLoaded returns the module acquired from the cache, or releases it and returns an error
+// if it's opened by [GetModule] and the file can't be loaded yet.
+func (m *Module) checkLoaded() (*Module, error) {
+	if gen := m.gen.Load(); m.filename == "" || gen == nil || gen.fileInfo != nil {
+		return m, nil
+	}
+
+	if _, err := os.Stat(m.filename); err != nil {
+		_ = m.Close()
+		return nil, fmt.Errorf("os.Stat(%s): %w", m.filename, err)
+	}
+
+	if err := m.Reload(); err != nil {
+		_ = m.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// openModule opens the module file and caches the module. If pending is true and the file can't be loaded,
+// a module without parameters is cached and returned with the error, it loads the file when it's created.
+func (l *Loader) openModule(key nameKey, inProgressByName chan<- struct{}, pending bool) (*Module, error) {
+	stored := false
+	defer func() {
+		if !stored {
+			modCache.abort(key, inProgressByName) // release the pending slot so a retry doesn't deadlock
+		}
+	}()
+
+	path, err := l.modFilePath(key.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var inProgressByPath chan<- struct{}
+
+	for {
+		var (
+			cached *Module
+			ok     bool
+		)
+
+		cached, inProgressByPath, ok = modCache.load(path)
+		if !ok {
+			break
+		}
+
+		if cached.acquire() {
+			cached.addCacheKey(key)
+			modCache.store(key, inProgressByName, cached) // re-cache by name if already cached by another name
+			stored = true
+
+			if pending {
+				return cached, nil
+			}
+
+			return cached.checkLoaded()
+		}
+
+		modCache.evict(path, cached)
+	}
+
+	defer func() {
+		if !stored {
+			modCache.abort(path, inProgressByPath)
+		}
+	}()
+
+	module := &Module{
+		name:         filepath.Base(path),
+		filename:     path,
+		refs:         1,
+		cacheKeys:    []any{key, path},
+		loadMode:     LoadMode(defaultLoadMode.Load()),
+		checksumMode: ChecksumMode(defaultChecksumMode.Load()),
+	}
+
+	module.SetLogger(l.opts.Logger)
+	module.SetEnvOverrides(l.opts.EnvOverrides)
+
+	openErr := module.reopen()
+	if openErr != nil {
+		if !pending {
+			return nil, openErr
+		}
+
+		module.install(newGeneration(emptyCDB(), nil, nil)) // any file found is loaded, since there's no file info
+	}
+
+	modCache.store(key, inProgressByName, module)
+	modCache.store(path, inProgressByPath, module)
+
+	stored = true
+
+	if err := module.SetWatcher(l.watcher()); err != nil {
+		return module, errors.Join(openErr, err)
+	}
+
+	return module, openErr
+}
+
+func (l *Loader) watcher() Watcher {
+	if l.opts.Watcher != nil {
+		return l.opts.Watcher
+	}
+
+	return getDefaultWatcher()
+}
+
+// modFilePath returns the absolute path of the module file.
+func (l *Loader) modFilePath(name string) (string, error) {
+	if filepath.Ext(name) == "" {
+		name += l.opts.Ext
+	}
+
+	if !strings.ContainsRune(name, filepath.Separator) {
+		found := filepath.Join(l.opts.Dirs[0], name)
+
+		for _, dir := range l.opts.Dirs {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				found = filepath.Join(dir, name)
+				break
+			}
+		}
+
+		name = found
+	}
+
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return "", fmt.Errorf("OpenModule(%s): error getting absolute path: %w", name, err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,108 @@
+package onlineconf
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWatcher struct {
+	files []string
+}
+
+func (w *recordingWatcher) Watch(filename string, _ func()) (func(), error) {
+	w.files = append(w.files, filename)
+	return func() {}, nil
+}
+
+func TestLoader(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeCDB(t, filepath.Join(dir1, "TREE.conf"), map[string]string{"/key": "dir1"})
+	writeCDB(t, filepath.Join(dir2, "TREE.conf"), map[string]string{"/key": "dir2"})
+	writeCDB(t, filepath.Join(dir2, "other.conf"), map[string]string{"/key": "other"})
+
+	var (
+		logs    bytes.Buffer
+		watcher recordingWatcher
+	)
+
+	loader := NewLoader(Options{
+		Dirs:    []string{dir1, dir2},
+		Ext:     ".conf",
+		Watcher: &watcher,
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+
+	mod, err := loader.OpenModule("TREE")
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "dir1", mod.GetString("/key", ""))
+	assert.Contains(t, logs.String(), "onlineconf: reopen")
+	assert.Contains(t, watcher.files, filepath.Join(dir1, "TREE.conf"))
+
+	other, err := loader.OpenModule("other")
+	require.NoError(t, err)
+
+	defer other.Close()
+
+	assert.Equal(t, "other", other.GetString("/key", ""), "the module must be found in the second directory")
+
+	pending := loader.getModule("pending")
+	defer pending.Close()
+
+	assert.Contains(t, watcher.files, filepath.Join(dir1, "pending.conf"), "the pending module must use the loader options")
+	assert.Contains(t, logs.String(), "onlineconf: can't open module")
+
+	// the same name is resolved by another loader to another file
+	t.Setenv(EnvOnlineConfDir, strings.Join([]string{dir2, dir1}, string(filepath.ListSeparator)))
+
+	envLoader := NewLoader(Options{Ext: ".conf", Watcher: &watcher})
+	assert.Equal(t, []string{dir2, dir1}, envLoader.opts.Dirs)
+
+	envMod, err := envLoader.OpenModule("TREE")
+	require.NoError(t, err)
+
+	defer envMod.Close()
+
+	assert.Equal(t, "dir2", envMod.GetString("/key", ""))
+
+	// the same file opened by another loader is shared
+	shared, err := envLoader.OpenModule(filepath.Join(dir1, "TREE.conf"))
+	require.NoError(t, err)
+
+	defer shared.Close()
+
+	assert.Same(t, mod, shared)
+
+	// a symlink may be swapped to point to another file, so it's another module
+	link := filepath.Join(t.TempDir(), "TREE.conf")
+	require.NoError(t, os.Symlink(filepath.Join(dir1, "TREE.conf"), link))
+
+	linked, err := loader.OpenModule(link)
+	require.NoError(t, err)
+
+	defer linked.Close()
+
+	assert.NotSame(t, mod, linked)
+	assert.Equal(t, "dir1", linked.GetString("/key", ""))
+}
+
+func TestLoaderDefaults(t *testing.T) {
+	t.Setenv(EnvOnlineConfDir, "")
+
+	loader := NewLoader(Options{})
+	assert.Equal(t, []string{DefaultOnlineConfPath}, loader.opts.Dirs)
+	assert.Equal(t, DefaultOnlineConfExt, loader.opts.Ext)
+
+	path, err := loader.modFilePath("TREE")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(DefaultOnlineConfPath, "TREE.cdb"), path)
+}
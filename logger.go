@@ -0,0 +1,179 @@
+package onlineconf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var packageLogger atomic.Pointer[slog.Logger]
+
+// logRepeatInterval is the minimal interval between logging of repeated getter errors.
+var logRepeatInterval = time.Minute
+
+// SetLogger sets the logger used by the package and by modules without their own logger
+// (see [Module.SetLogger]). Passing nil restores the default logger, [slog.Default].
+//
+// Messages are logged with the following levels:
+//
+//	Info  - reloads of module files, e.g. "onlineconf: reopen"
+//	Warn  - errors of GetXXX methods (other than a non-existing parameter), the default value is returned
+//	Error - reload failures and errors of the file watcher
+//
+// Reload messages can be silenced by a logger with a handler level above [slog.LevelInfo].
+//
+// Attributes "module", "path", "type" (of the value requested from a getter), "kind" (of the error)
+// and "error" are added to messages where applicable.
+// Repeated getter errors with the same module, path, type and kind are logged at most once a minute
+// (and once per version of the module file), the next message logged has the "suppressed" attribute
+// with the number of messages suppressed.
+func SetLogger(l *slog.Logger) {
+	packageLogger.Store(l)
+}
+
+func logger() *slog.Logger {
+	if l := packageLogger.Load(); l != nil {
+		return l
+	}
+
+	return slog.Default()
+}
+
+// SetLogger sets the logger used by the module. Passing nil makes the module use the package logger
+// set by the package-level [SetLogger].
+func (m *Module) SetLogger(l *slog.Logger) {
+	m.ownLogger.Store(l)
+}
+
+func (m *Module) logger() *slog.Logger {
+	if l := m.ownLogger.Load(); l != nil {
+		return l
+	}
+
+	return logger()
+}
+
+// logGetError logs an error of a getter of the type unless the same error of the path was logged recently.
+func (m *Module) logGetError(path, typ string, err error) {
+	kind := errorKind(err)
+
+	ok, suppressed := m.logLimiter.allow(logKey{path: path, typ: typ, kind: kind})
+	if !ok {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("module", m.name),
+		slog.String("path", path),
+		slog.String("type", typ),
+		slog.String("kind", kind),
+		slog.Any("error", err),
+	}
+
+	if suppressed != 0 {
+		attrs = append(attrs, slog.Int("suppressed", suppressed))
+	}
+
+	m.logger().LogAttrs(context.Background(), slog.LevelWarn, "onlineconf: can't get value", attrs...)
+}
+
+// logError logs an error not related to any path.
+func (m *Module) logError(msg string, err error) {
+	m.logger().LogAttrs(context.Background(), slog.LevelError, msg,
+		slog.String("module", m.name),
+		slog.String("kind", errorKind(err)),
+		slog.Any("error", err),
+	)
+}
+
+// errorKind classifies errors for the "kind" log attribute.
+func errorKind(err error) string {
+	var (
+		numErr       *strconv.NumError
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.Is(err, ErrFormatIsNotString), errors.Is(err, ErrFormatIsNotJSON):
+		return "format"
+	case errors.Is(err, ErrClosed):
+		return "closed"
+	case errors.Is(err, ErrRejected):
+		return "rejected"
+	case errors.Is(err, ErrNoDecoder):
+		return "decoder"
+	case errors.Is(err, ErrInvalidFile), errors.Is(err, ErrFileChanged):
+		return "file"
+	case errors.Is(err, ErrChecksum):
+		return "checksum"
+	case errors.As(err, &numErr):
+		return "parse"
+	case errors.As(err, &syntaxErr), errors.As(err, &unmarshalErr):
+		return "json"
+	case errors.As(err, &parseError{}):
+		return "parse"
+	default:
+		return "other"
+	}
+}
+
+type logKey struct {
+	path string
+	typ  string
+	kind string
+}
+
+type logEntry struct {
+	loggedAt   time.Time
+	suppressed int
+}
+
+// logLimiter suppresses repeated getter errors. The zero value is ready to use.
+type logLimiter struct {
+	mutex   sync.Mutex
+	entries map[logKey]*logEntry
+}
+
+// allow returns whether an error should be logged and the number of errors suppressed since the last one logged.
+func (l *logLimiter) allow(key logKey) (bool, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		if l.entries == nil {
+			l.entries = make(map[logKey]*logEntry)
+		}
+
+		l.entries[key] = &logEntry{loggedAt: now}
+
+		return true, 0
+	}
+
+	if now.Sub(entry.loggedAt) < logRepeatInterval {
+		entry.suppressed++
+		return false, 0
+	}
+
+	suppressed := entry.suppressed
+	entry.loggedAt, entry.suppressed = now, 0
+
+	return true, suppressed
+}
+
+// reset forgets all the errors logged, e.g. when a new version of a module file is loaded.
+// The number of suppressed errors is lost.
+func (l *logLimiter) reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = nil
+}
@@ -0,0 +1,117 @@
+package onlineconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})), buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+
+	for line := range strings.Lines(buf.String()) {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+
+		records = append(records, rec)
+	}
+
+	return records
+}
+
+func TestLogGetErrors(t *testing.T) {
+	mod, err := NewModuleFromMap("logger", map[string]any{"/int": "x", "/json": json.RawMessage(`{}`)})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	l, buf := newTestLogger(slog.LevelInfo)
+	mod.SetLogger(l)
+
+	for range 3 {
+		assert.Equal(t, 1, mod.GetInt("/int", 1))
+	}
+
+	assert.Equal(t, "", mod.GetString("/json", ""))
+	assert.Equal(t, 0, mod.Subtree("/").GetInt("/int", 0))
+
+	records := logRecords(t, buf)
+	require.Len(t, records, 2, "repeated errors must be suppressed")
+
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "logger", records[0]["module"])
+	assert.Equal(t, "/int", records[0]["path"])
+	assert.Equal(t, "int", records[0]["type"])
+	assert.Equal(t, "parse", records[0]["kind"])
+	assert.Contains(t, records[0]["error"], "invalid syntax")
+
+	assert.Equal(t, "/json", records[1]["path"])
+	assert.Equal(t, "string", records[1]["type"])
+	assert.Equal(t, "format", records[1]["kind"])
+
+	defer func(interval time.Duration) { logRepeatInterval = interval }(logRepeatInterval)
+
+	logRepeatInterval = 0
+	buf.Reset()
+
+	assert.Equal(t, 1, mod.GetInt("/int", 1))
+
+	records = logRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, float64(3), records[0]["suppressed"])
+}
+
+func TestSetLogger(t *testing.T) {
+	packageLog, packageBuf := newTestLogger(slog.LevelWarn)
+	SetLogger(packageLog)
+
+	defer SetLogger(nil)
+
+	cdbName := filepath.Join(t.TempDir(), "logger.cdb")
+	writeCDB(t, cdbName, map[string]string{"/int": "x"})
+
+	mod, err := OpenModule(cdbName)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	writeCDB(t, cdbName, map[string]string{"/int": "y"})
+	require.NoError(t, mod.Reload())
+
+	assert.Empty(t, packageBuf.String(), "reload messages must be silenced by the Warn level")
+
+	assert.Equal(t, 1, mod.GetInt("/int", 1))
+	assert.Contains(t, packageBuf.String(), `"path":"/int"`, "the package logger must be used by default")
+
+	moduleLog, moduleBuf := newTestLogger(slog.LevelInfo)
+	mod.SetLogger(moduleLog)
+	packageBuf.Reset()
+
+	writeCDB(t, cdbName, map[string]string{"/int": "z"})
+	require.NoError(t, mod.Reload())
+
+	records := logRecords(t, moduleBuf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "onlineconf: reopen", records[0]["msg"])
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, cdbName, records[0]["file"])
+
+	moduleBuf.Reset()
+
+	_, ok := GetIfExists[int](mod, "/int")
+	assert.False(t, ok)
+	assert.Contains(t, moduleBuf.String(), `"type":"int"`, "the module logger must be used by generic getters")
+	assert.Empty(t, packageBuf.String())
+}
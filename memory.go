@@ -0,0 +1,86 @@
+package onlineconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/colinmarc/cdb"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+)
+
+// NewModule creates a [Module] reading a CDB image from r.
+//
+// Unlike [OpenModule], the module isn't cached and isn't tracked for changes,
+// so subscriptions never fire. The name argument is used in error messages only.
+// The module doesn't close r, it's up to the caller to close it after [Module.Close].
+func NewModule(name string, r io.ReaderAt) (*Module, error) {
+	cdb, err := cdb.New(r, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cdb.New(%s): %w", name, err)
+	}
+
+	m := &Module{
+		name: name,
+		refs: 1,
+	}
+
+	m.install(newGeneration(cdb, nil, nil))
+
+	return m, nil
+}
+
+// NewModuleFromBytes creates a [Module] from a CDB image, e.g. embedded using the go:embed directive.
+// See [NewModule] for details.
+func NewModuleFromBytes(name string, data []byte) (*Module, error) {
+	return NewModule(name, bytes.NewReader(data))
+}
+
+// NewModuleFromMap creates a [Module] from a configuration tree. See [NewModule] for details.
+//
+// Keys are joined with the parent path, so both nested trees like
+//
+//	map[string]any{"db": map[string]any{"host": "localhost", "port": 5432}}
+//
+// and flat maps like
+//
+//	map[string]string{"/db/host": "localhost", "/db/port": "5432"}
+//
+// are accepted. Map values with string keys are treated as subtrees. Other values are
+// stored as text ("s") values if they are strings, numbers, booleans, or [time.Duration] values,
+// and as JSON ("j") values otherwise. Use [encoding/json.RawMessage] to store a JSON object.
+//
+// Child lists are generated, so [Module.SubscribeSubtree] and other features depending on
+// `child_lists` work as expected.
+func NewModuleFromMap[V any](name string, tree map[string]V) (*Module, error) {
+	records, err := cdbtree.Flatten(tree)
+	if err != nil {
+		return nil, fmt.Errorf("NewModuleFromMap(%s): %w", name, err)
+	}
+
+	data, err := cdbtree.Build(records)
+	if err != nil {
+		return nil, fmt.Errorf("NewModuleFromMap(%s): %w", name, err)
+	}
+
+	return NewModuleFromBytes(name, data)
+}
+
+// NewModuleFromJSON creates a [Module] from a configuration tree encoded as a JSON object,
+// e.g. a local override file. See [NewModule] for details.
+//
+// Objects are treated as subtrees like maps by [NewModuleFromMap]. Strings, numbers and booleans
+// are stored as text values (booleans as "1" and "0"), null as an empty text value,
+// and arrays as JSON values. Objects can't be stored as JSON values, since they are subtrees.
+func NewModuleFromJSON(name string, data []byte) (*Module, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var tree map[string]any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("NewModuleFromJSON(%s): %w", name, err)
+	}
+
+	return NewModuleFromMap(name, tree)
+}
@@ -0,0 +1,94 @@
+package onlineconf
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewModuleFromMap(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]any{
+		"db": map[string]any{
+			"host":    "localhost",
+			"port":    5432,
+			"timeout": 3 * time.Second,
+			"replica": true,
+		},
+		"/list/hosts": []string{"a", "b"},
+		"/struct":     json.RawMessage(`{"key0":"value0"}`),
+		"/empty":      nil,
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "localhost", mod.GetString("/db/host", ""))
+	assert.Equal(t, 5432, mod.GetInt("/db/port", 0))
+	assert.Equal(t, 3*time.Second, mod.GetDuration("/db/timeout", 0))
+	assert.True(t, mod.GetBool("/db/replica", false))
+	assert.Equal(t, []string{"a", "b"}, mod.GetStrings("/list/hosts", nil))
+	assert.Equal(t, "", mod.GetString("/empty", "value"), "an empty value isn't a missing one")
+
+	var val testStruct
+
+	ok, err := mod.GetStruct("/struct", &val)
+	assert.True(t, ok)
+	require.NoError(t, err)
+	assert.Equal(t, testStruct{Key0: "value0"}, val)
+
+	assert.Equal(t, 5432, mod.Subtree("/db").GetInt("/port", 0))
+
+	children, err := mod.getStringsRaw("/db/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host", "port", "replica", "timeout"}, children)
+
+	_, err = mod.Subscribe("/db/host")
+	require.NoError(t, err)
+}
+
+func TestNewModuleFromBytes(t *testing.T) {
+	records, err := cdbtree.Flatten(map[string]string{"/key": "value"})
+	require.NoError(t, err)
+
+	data, err := cdbtree.Build(records)
+	require.NoError(t, err)
+
+	mod, err := NewModuleFromBytes("test", data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "value", mod.GetString("/key", ""))
+
+	require.NoError(t, mod.Close())
+
+	_, err = mod.GetStringErr("/key")
+	assert.True(t, errors.Is(err, ErrClosed), "reading a closed module must fail")
+
+	_, err = NewModuleFromBytes("broken", []byte("too short"))
+	assert.Error(t, err)
+}
+
+func TestNewModuleFromJSON(t *testing.T) {
+	mod, err := NewModuleFromJSON("local", []byte(`{
+		"db": {"host": "localhost", "port": 5432, "replica": true, "ratio": 0.5},
+		"/list/hosts": ["a", "b"],
+		"/empty": null
+	}`))
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "localhost", mod.GetString("/db/host", ""))
+	assert.Equal(t, 5432, mod.GetInt("/db/port", 0))
+	assert.True(t, mod.GetBool("/db/replica", false))
+	assert.InDelta(t, 0.5, mod.GetFloat("/db/ratio", 0), 1e-9)
+	assert.Equal(t, []string{"a", "b"}, mod.GetStrings("/list/hosts", nil))
+	assert.Equal(t, "", mod.GetString("/empty", "default"))
+
+	_, err = NewModuleFromJSON("invalid", []byte(`["not", "an", "object"]`))
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package onlineconf
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// GetResult is a result of a GetXXX call reported to [Metrics].
+type GetResult int
+
+const (
+	GetOK          GetResult = iota // the value is returned
+	GetNotFound                     // the parameter doesn't exist
+	GetFormatError                  // the parameter has a format (text or JSON) not suitable for the type
+	GetParseError                   // the value can't be parsed or decoded to the type
+	GetError                        // any other error, e.g. a CDB error or a closed module
+)
+
+var getResultNames = [...]string{
+	GetOK:          "hit",
+	GetNotFound:    "notfound",
+	GetFormatError: "format_error",
+	GetParseError:  "parse_error",
+	GetError:       "error",
+}
+
+func (r GetResult) String() string {
+	if r < 0 || int(r) >= len(getResultNames) {
+		return "unknown"
+	}
+
+	return getResultNames[r]
+}
+
+// Metrics receives measurements of the library. It's set using [SetMetrics].
+//
+// Methods are called synchronously from getters and while module locks are held,
+// so they must be fast, safe for concurrent use, and must not call methods of modules.
+// The module argument is the path of the module file, or the name passed to NewModule*
+// for modules which aren't loaded from files.
+type Metrics interface {
+	// Get is called on every GetXXX call (including generic getters and [Module.GetStruct])
+	// with the name of the type requested, e.g. "int" or "duration", and the result.
+	// Generic getters report types having typed getters by the same names, and all other types as "generic".
+	// Subtree and snapshot getters are counted as getters of their modules.
+	Get(module, typ string, result GetResult)
+
+	// Cache is called on every lookup in the value cache used by [Module.GetStrings],
+	// [Module.GetStruct] and generic getters.
+	Cache(module string, hit bool)
+
+	// Reload is called after every attempt to load a new version of a module file
+	// (including the initial load) with its duration and error.
+	Reload(module string, duration time.Duration, err error)
+
+	// Subscriptions is called with the number of channels subscribed to the module
+	// (including event channels) every time the number changes.
+	Subscriptions(module string, n int)
+
+	// NotificationDropped is called every time a notification isn't sent to a subscribed channel
+	// since the channel is busy (see [Module.SubscribeChan]).
+	NotificationDropped(module string)
+}
+
+var metrics atomic.Pointer[Metrics]
+
+// SetMetrics sets the metrics receiver for all the modules. Passing nil disables metrics,
+// which is the default.
+func SetMetrics(mt Metrics) {
+	if mt == nil {
+		metrics.Store(nil)
+		return
+	}
+
+	metrics.Store(&mt)
+}
+
+func loadMetrics() Metrics {
+	if mt := metrics.Load(); mt != nil {
+		return *mt
+	}
+
+	return nil
+}
+
+func (m *Module) countGet(typ string, err error) {
+	if mt := loadMetrics(); mt != nil {
+		mt.Get(m.location(), typ, getResult(err))
+	}
+}
+
+func getResult(err error) GetResult {
+	if err == nil {
+		return GetOK
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		return GetNotFound
+	}
+
+	switch errorKind(err) {
+	case "format":
+		return GetFormatError
+	case "parse", "json":
+		return GetParseError
+	default:
+		return GetError
+	}
+}
+
+func (m *Module) countCache(hit bool) {
+	if mt := loadMetrics(); mt != nil {
+		mt.Cache(m.location(), hit)
+	}
+}
+
+func (m *Module) countReload(duration time.Duration, err error) {
+	if mt := loadMetrics(); mt != nil {
+		mt.Reload(m.location(), duration, err)
+	}
+}
+
+func (m *Module) countDropped() {
+	if mt := loadMetrics(); mt != nil {
+		mt.NotificationDropped(m.location())
+	}
+}
+
+// countSubscriptions reports the number of subscribed channels if it's changed.
+// The caller must hold m.mutex.
+func (m *Module) countSubscriptions() {
+	n := 0
+
+	for _, sub := range m.subscriptions {
+		n += len(sub.channels)
+	}
+
+	for _, sub := range m.eventSubscriptions {
+		n += len(sub.channels)
+	}
+
+	if n == m.subscribed {
+		return
+	}
+
+	m.subscribed = n
+
+	if mt := loadMetrics(); mt != nil {
+		mt.Subscriptions(m.location(), n)
+	}
+}
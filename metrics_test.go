@@ -0,0 +1,119 @@
+package onlineconf
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	mutex         sync.Mutex
+	modules       map[string]int
+	gets          map[string]int
+	cache         map[bool]int
+	subscriptions []int
+	dropped       int
+}
+
+func (tm *testMetrics) Get(module, typ string, result GetResult) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.modules[module]++
+	tm.gets[typ+":"+result.String()]++
+}
+
+func (tm *testMetrics) Cache(_ string, hit bool) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.cache[hit]++
+}
+
+func (*testMetrics) Reload(string, time.Duration, error) {}
+
+func (tm *testMetrics) Subscriptions(_ string, n int) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.subscriptions = append(tm.subscriptions, n)
+}
+
+func (tm *testMetrics) NotificationDropped(string) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.dropped++
+}
+
+func TestMetrics(t *testing.T) {
+	tm := &testMetrics{modules: map[string]int{}, gets: map[string]int{}, cache: map[bool]int{}}
+
+	SetMetrics(tm)
+	defer SetMetrics(nil)
+
+	mod, err := NewModuleFromMap("metrics", map[string]any{"/int": "1", "/float": "x", "/strings": "a,b"})
+	require.NoError(t, err)
+
+	mod.GetInt("/int", 0)
+	mod.GetIntIfExists("/int")
+	_, _ = mod.GetIntErr("/none")
+	mod.GetFloat("/float", 0)
+	mod.Subtree("/").GetStrings("/strings", nil)
+	mod.GetStrings("/strings", nil)
+	_, _ = GetErr[int](mod, "/float")
+	_, _ = GetErr[time.Duration](mod, "/none")
+	_, _ = GetErr[testLevel](mod, "/none")
+
+	assert.Equal(t, map[string]int{
+		"int:hit":           2,
+		"int:notfound":      1,
+		"int:parse_error":   1, // generic getters are counted once
+		"float:parse_error": 1,
+		"strings:hit":       2,
+		"duration:notfound": 1,
+		"generic:notfound":  1,
+	}, tm.gets)
+	assert.Equal(t, map[bool]int{false: 4, true: 1}, tm.cache)
+	assert.Equal(t, map[string]int{"metrics": 9}, tm.modules)
+
+	ch1, err := mod.Subscribe("/int")
+	require.NoError(t, err)
+
+	_, err = mod.SubscribeEvents("/int")
+	require.NoError(t, err)
+
+	mod.UnsubscribeChan("/int", ch1)
+	require.NoError(t, mod.Close())
+
+	assert.Equal(t, []int{1, 2, 1, 0}, tm.subscriptions)
+}
+
+func TestMetricsModuleNames(t *testing.T) {
+	tm := &testMetrics{modules: map[string]int{}, gets: map[string]int{}, cache: map[bool]int{}}
+
+	SetMetrics(tm)
+	defer SetMetrics(nil)
+
+	var filenames []string
+
+	for _, dir := range []string{t.TempDir(), t.TempDir()} {
+		filename := filepath.Join(dir, "TREE.cdb")
+		writeCDB(t, filename, map[string]string{"/key": "value"})
+
+		mod, err := OpenModule(filename)
+		require.NoError(t, err)
+
+		t.Cleanup(func() { _ = mod.Close() })
+
+		mod.GetString("/key", "")
+
+		filenames = append(filenames, filename)
+	}
+
+	assert.Equal(t, map[string]int{filenames[0]: 1, filenames[1]: 1}, tm.modules, "modules with the same base name must be told apart")
+}
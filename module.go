@@ -1,23 +1,22 @@
 package onlineconf
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"path"
-	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/colinmarc/cdb"
-	"github.com/my-mail-ru/exp/mmap"
 )
 
-// CDB path defaults
+// CDB path defaults used by loaders unless overridden by [Options] or the ONLINECONF_DIR environment variable
 const (
 	DefaultOnlineConfPath = "/usr/local/etc/onlineconf"
 	DefaultOnlineConfExt  = ".cdb"
@@ -28,142 +27,331 @@ var (
 	ErrNotFound          = errors.New("onlineconf: key not found")
 	ErrFormatIsNotString = errors.New("format is not a string")
 	ErrFormatIsNotJSON   = errors.New("format is not JSON")
+	ErrClosed            = errors.New("onlineconf: module is closed")
 )
 
+// parseError marks errors of parsing or decoding of values for metrics and logging, its message is unchanged.
+type parseError struct {
+	error
+}
+
+func (err parseError) Unwrap() error {
+	return err.error
+}
+
 // Module represents a CDB configuration database.
 type Module struct {
-	mutex         sync.RWMutex
-	name          string // CDB relative file name, used in error messages. not a name passed to OpenModule
-	filename      string // full CDB file path
-	cache         valueCache
-	mmappedFile   *mmap.ReaderAt
-	cdb           *cdb.CDB
-	subscriptions map[subscriptionKey]subscription
+	mutex              sync.Mutex                 // protects everything but getters, which read gen only
+	reloadMutex        sync.Mutex                 // serializes reloads including calls of reload callbacks
+	name               string                     // CDB relative file name, used in error messages. not a name passed to OpenModule
+	filename           string                     // full CDB file path with symlinks unresolved (they may be swapped), empty for modules created by NewModule*
+	gen                atomic.Pointer[generation] // the current generation, nil if the module is closed; swapped under mutex
+	subscriptions      map[subscriptionKey]subscription
+	eventSubscriptions map[subscriptionKey]*eventSubscription
+	reloadCallbacks    []func([]Change)
+	validators         []func(*Snapshot) error
+	rejectCallbacks    []func(error)
+	errorCallbacks     []func(error)
+	generation         uint64    // number of versions loaded
+	loadedAt           time.Time // time the current version was loaded
+	lastError          error
+	lastErrorAt        time.Time
+	ownLogger          atomic.Pointer[slog.Logger] // set by SetLogger
+	subscribed         int                         // number of subscribed channels reported to metrics
+	logLimiter         logLimiter
+	refs               int                          // number of OpenModule calls not balanced by Close yet
+	closed             bool                         // set by the last Close call, the module can't be reused after that
+	cacheKeys          []any                        // modCache keys the module is stored under
+	stopWatch          func()                       // stops tracking the file, nil if the watcher failed
+	loadMode           LoadMode                     // set by SetLoadMode
+	checksumMode       ChecksumMode                 // set by SetChecksumMode
+	env                atomic.Pointer[envOverrides] // set by SetEnvOverrides, nil if disabled
 }
 
-var modCache syncCache[*Module]
-
-// OpenModule opens a CDB configuration database.
+// OpenModule opens a CDB configuration database using the default [Loader], see [NewLoader].
 //
 // If the name argument doesn't contain a filesystem path separator (usually '/'),
-// it is treated as relative to [DefaultOnlineConfPath] (/usr/local/etc/onlineconf),
-// in the other case - as an absolute or a relative path.
+// it is treated as relative to the directory set by the ONLINECONF_DIR environment variable
+// or [DefaultOnlineConfPath] (/usr/local/etc/onlineconf), in the other case - as an absolute or a relative path.
 // If no extension is specified, the default `.cdb` extension is appended.
 // There's no way to specify a file without an extension.
 //
 // Calling OpenModule multiple times with the same file argument (regardless of the
-// actual mode used, e.g., relative or absolute) will always return the same value.
+// actual mode used, e.g., relative or absolute) will always return the same value
+// until the module is closed.
 //
 // The file opened is tracked for changes using the [fsnotify] library and is reloaded when a change is detected.
 // See [Module.Subscribe], [Module.SubscribeChan], [Module.SubscribeSubtree] and [Module.SubscribeChanSubtree] methods
 // for a description of high-level value change notification mechanism.
 //
-// Currently, there's no way to "close" a [Module].
+// Symlinks in the path are resolved on every reload rather than once, so a module keeps following the path
+// when a symlink to the file or to its directory is atomically replaced, as in Kubernetes ConfigMap volumes.
+//
+// Every successful call to OpenModule should be balanced by a call to [Module.Close]
+// if the module is no longer needed. Modules are reference counted, so the module is
+// really closed only when all its users have closed it.
 func OpenModule(name string) (*Module, error) {
-	cached, inProgressByName, ok := modCache.load(name)
-	if ok {
-		return cached, nil
+	return defaultLoader().OpenModule(name)
+}
+
+// acquire increments the reference counter of a cached module.
+// It returns false if the module is already closed.
+func (m *Module) acquire() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return false
 	}
 
-	stored := false
-	defer func() {
-		if !stored {
-			modCache.abort(name, inProgressByName) // release the pending slot so a retry doesn't deadlock
+	m.refs++
+
+	return true
+}
+
+func (m *Module) addCacheKey(key any) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, k := range m.cacheKeys {
+		if k == key {
+			return
 		}
-	}()
+	}
 
-	filename, err := modFileName(name)
-	if err != nil {
-		return nil, err
+	m.cacheKeys = append(m.cacheKeys, key)
+}
+
+// Close releases the module obtained by [OpenModule].
+//
+// The module is really closed only when Close is called as many times as OpenModule
+// returned this module. After that the file is unmapped (after reads in progress are done and snapshots
+// are released), the module directory is no longer watched (if there are no other modules opened in it),
+// all subscribed channels are closed, and the following OpenModule call opens the file again.
+// All Get* methods of the closed module return [ErrClosed] or default values.
+//
+// Calling Close on an already closed module returns [ErrClosed].
+func (m *Module) Close() error {
+	m.mutex.Lock()
+
+	if m.closed {
+		m.mutex.Unlock()
+		return ErrClosed
 	}
 
-	var inProgressByFileName chan<- struct{}
+	if m.refs > 1 {
+		m.refs--
+		m.mutex.Unlock()
 
-	if filename != name {
-		cached, inProgressByFileName, ok = modCache.load(filename)
-		if ok {
-			modCache.store(name, inProgressByName, cached) // re-cache by relative/short name if already cached by fully qualified name
-			stored = true
-			return cached, nil
-		}
+		return nil
+	}
+
+	m.refs = 0
+	m.closed = true
+
+	cacheKeys := m.cacheKeys
+	stopWatch := m.stopWatch
+	subscriptions := m.subscriptions
+	eventSubscriptions := m.eventSubscriptions
+
+	gen := m.gen.Swap(nil)
+	m.stopWatch = nil
+	m.subscriptions = nil
+	m.eventSubscriptions = nil
+	m.countSubscriptions()
 
-		defer func() {
-			if !stored {
-				modCache.abort(filename, inProgressByFileName)
-			}
-		}()
+	m.mutex.Unlock()
+
+	for _, key := range cacheKeys {
+		modCache.evict(key, m)
 	}
 
-	module := &Module{
-		name:     filepath.Base(filename),
-		filename: filename,
+	if stopWatch != nil {
+		stopWatch()
 	}
 
-	if err := module.reopen(); err != nil {
-		return nil, err
+	for _, sub := range subscriptions {
+		for ch := range sub.channels {
+			safeClose(ch)
+		}
 	}
 
-	modCache.store(name, inProgressByName, module)
+	for _, sub := range eventSubscriptions {
+		for ch := range sub.channels {
+			safeClose(ch)
+		}
+	}
 
-	if filename != name {
-		modCache.store(filename, inProgressByFileName, module)
+	if gen == nil { // the default module may have never been loaded
+		return nil
 	}
 
-	stored = true
+	if err := gen.release(); err != nil {
+		return fmt.Errorf("%s: unmap: %w", m.location(), err)
+	}
 
-	return module, initWatcher(filepath.Dir(filename))
+	return nil
 }
 
-func modFileName(name string) (string, error) {
-	if !strings.ContainsRune(name, filepath.Separator) {
-		name = filepath.Join(DefaultOnlineConfPath, name)
+// Reload rereads the module file if it was replaced since the last (re)load,
+// and sends notifications to subscribers of changed values.
+// The method returns after all the notifications are sent.
+//
+// There's usually no need to call Reload since module files are tracked for changes,
+// but it's useful in tests to wait until a new file is loaded deterministically.
+// Reload does nothing for modules created by NewModule*.
+func (m *Module) Reload() error {
+	if m.filename == "" {
+		return nil
 	}
 
-	if filepath.Ext(name) == "" {
-		name += DefaultOnlineConfExt
+	return m.reopen()
+}
+
+func (m *Module) reopen() error {
+	m.reloadMutex.Lock()
+	defer m.reloadMutex.Unlock()
+
+	initial := m.gen.Load() == nil // errors of the initial load are returned by OpenModule
+
+	start := time.Now()
+
+	res, err := m.load()
+	if res != nil || err != nil && !errors.Is(err, ErrClosed) {
+		m.countReload(time.Since(start), err)
 	}
 
-	filename, err := filepath.Abs(name)
 	if err != nil {
-		return "", fmt.Errorf("OpenModule(%s): error getting absolute path: %w", name, err)
+		if initial || errors.Is(err, ErrClosed) {
+			return err
+		}
+
+		if errors.Is(err, ErrRejected) {
+			m.reject(err)
+		}
+
+		m.reportError(err)
+
+		return err
 	}
 
-	filename, err = filepath.EvalSymlinks(filename)
-	if err != nil {
-		return "", fmt.Errorf("OpenModule(%s): error resolving symlinks: %w", name, err)
+	if res == nil {
+		return nil
+	}
+
+	for _, err := range res.errs {
+		m.reportError(err)
 	}
 
-	return filename, nil
+	for _, cb := range res.callbacks {
+		cb(res.changes)
+	}
+
+	return nil
 }
 
-func (m *Module) reopen() error {
-	log.Printf("onlineconf: reopen %s", m.filename)
+// loaded describes a new version of the module file installed by load.
+type loaded struct {
+	changes   []Change
+	callbacks []func([]Change) // reload callbacks to call with the changes
+	errs      []error          // non-fatal errors occurred while installing the new version
+}
+
+// load maps the module file if it was replaced. It returns nil if the file is already loaded.
+// The caller must hold m.reloadMutex.
+func (m *Module) load() (*loaded, error) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	closed, validators, mode, checksumMode := m.closed, m.validators, m.loadMode, m.checksumMode
+	m.mutex.Unlock()
 
-	oldMmappedFile := m.mmappedFile
+	if closed { // the watcher may race with Close
+		return nil, ErrClosed
+	}
 
-	mmappedFile, err := mmap.Open(m.filename)
+	fileInfo, err := os.Stat(m.filename)
 	if err != nil {
-		return fmt.Errorf("mmap.Open(%s): %w", m.filename, err)
+		return nil, fmt.Errorf("os.Stat(%s): %w", m.filename, err)
+	}
+
+	oldGen := m.gen.Load() // generations are swapped by load only, so it's stable until Close
+
+	if oldGen != nil && isSameFile(oldGen.fileInfo, fileInfo) { // already loaded, e.g. by Reload
+		return nil, nil
+	}
+
+	if oldGen != nil && os.SameFile(oldGen.fileInfo, fileInfo) {
+		m.logger().LogAttrs(context.Background(), slog.LevelWarn, "onlineconf: file modified in place",
+			slog.String("module", m.name),
+			slog.String("file", m.filename),
+			slog.String("mode", mode.String()),
+		)
 	}
 
-	cdb, err := cdb.New(mmappedFile, nil)
+	m.logger().LogAttrs(context.Background(), slog.LevelInfo, "onlineconf: reopen",
+		slog.String("module", m.name),
+		slog.String("file", m.filename),
+	)
+
+	gen, err := openGeneration(m.filename, mode, checksumMode)
 	if err != nil {
-		mmappedFile.Close()
-		return fmt.Errorf("cdb.New(%s): %w", m.filename, err)
+		return nil, fmt.Errorf("%s: %w", m.filename, err)
 	}
 
-	m.cdb = cdb
+	if err := m.validate(gen, validators); err != nil { // called without m.mutex, validators may use the module
+		m.releaseGen(gen)
+		return nil, err
+	}
 
-	if oldMmappedFile != nil {
-		oldMmappedFile.Close()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		m.releaseGen(gen)
+		return nil, ErrClosed
 	}
 
-	m.cache.init()
-	m.processSubscriptions()
+	res := &loaded{callbacks: m.reloadCallbacks}
 
-	return nil
+	if len(res.callbacks) != 0 && oldGen != nil {
+		if res.changes, err = diffCDB(oldGen.cdb, gen.cdb); err != nil {
+			res.errs = append(res.errs, fmt.Errorf("%s: diff failed: %w", m.filename, err))
+			res.callbacks = nil
+		}
+	}
+
+	if oldGen := m.install(gen); oldGen != nil { // unmapped here or by the last reader still using it
+		if err := oldGen.release(); err != nil {
+			res.errs = append(res.errs, fmt.Errorf("%s: unmap: %w", m.filename, err))
+		}
+	}
+
+	if err := m.processSubscriptions(); err != nil {
+		res.errs = append(res.errs, err)
+	}
+
+	if err := m.processEventSubscriptions(); err != nil {
+		res.errs = append(res.errs, err)
+	}
+
+	m.countSubscriptions() // closed channels are unsubscribed
+
+	return res, nil
+}
+
+// install makes the generation current taking over the caller's ownership of it, and returns the previous one,
+// which is still owned by the module. The caller must hold m.mutex unless the module isn't shared yet.
+func (m *Module) install(gen *generation) *generation {
+	old := m.gen.Swap(gen)
+	m.resolveEnv()
+	m.generation++
+	m.loadedAt = time.Now()
+	m.logLimiter.reset() // errors of the new version are new
+
+	return old
+}
+
+func isSameFile(old, new os.FileInfo) bool {
+	return old != nil && os.SameFile(old, new) && old.Size() == new.Size() && old.ModTime().Equal(new.ModTime())
 }
 
 // get never returns ErrNotFound.
@@ -173,26 +361,118 @@ func (m *Module) reopen() error {
 //	's' - any text value including numbers, strings, and bools (since onlineconf UI doesn't support strict typing)
 //	'j' - JSON or YAML (which is converted to JSON in the updater)
 func (m *Module) get(path string) (byte, []byte, error) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	format, data, _, err := m.lookup(path)
+	return format, data, err
+}
+
+// lookup is get also returning the cache of the generation the value is read from.
+func (m *Module) lookup(path string) (byte, []byte, *valueCache, error) {
+	gen, shard := m.pin()
+	if gen == nil {
+		return 0, nil, nil, ErrClosed
+	}
+
+	data, err := m.genRaw(gen, path)
+	m.unpin(gen, shard)
+
+	if env := m.env.Load(); env != nil {
+		if format, value, ok := m.lookupEnv(env, path, data); ok {
+			return format, value, nil, nil // overridden values aren't cached
+		}
+	}
 
-	data, err := m.getRaw(path)
 	if len(data) == 0 {
-		return 0, nil, err
+		return 0, nil, nil, err
+	}
+
+	return data[0], data[1:], &gen.cache, nil
+}
+
+// pin returns the current generation registered as read by the caller, or nil if the module is closed.
+// It never blocks: if the generation is being retired concurrently by a reload, the new one is taken.
+// The shard returned must be passed to unpin.
+func (m *Module) pin() (*generation, int) {
+	for {
+		gen := m.gen.Load()
+		if gen == nil {
+			return nil, -1
+		}
+
+		shard, ok := gen.enter()
+		if ok {
+			return gen, shard
+		}
+
+		m.unpin(gen, shard)
+	}
+}
+
+// unpin unregisters the reader of the generation returned by pin.
+func (m *Module) unpin(gen *generation, shard int) {
+	if err := gen.exit(shard); err != nil {
+		m.logError("onlineconf: unmap failed", fmt.Errorf("%s: unmap: %w", m.location(), err))
+	}
+}
+
+// releaseGen releases a generation owned by the caller.
+func (m *Module) releaseGen(gen *generation) {
+	if err := gen.release(); err != nil {
+		m.logError("onlineconf: unmap failed", fmt.Errorf("%s: unmap: %w", m.location(), err))
+	}
+}
+
+// cache returns the value cache of the current generation.
+// The cache isn't mapped, so it's safe to use without pinning the generation.
+func (m *Module) cache() *valueCache {
+	gen := m.gen.Load()
+	if gen == nil {
+		return &valueCache{}
+	}
+
+	return &gen.cache
+}
+
+// cacheGet gets a value from the value cache of the current generation.
+func (m *Module) cacheGet(path string, rv reflect.Value) bool {
+	if env := m.env.Load(); env != nil && env.lookup(path) != nil { // the value may be cached before overrides are enabled
+		return false
 	}
 
-	return data[0], data[1:], nil
+	hit := m.cache().get(path, rv)
+	m.countCache(hit)
+
+	return hit
 }
 
+// getRaw reads the current generation, so the caller must hold m.mutex to prevent reloads.
 func (m *Module) getRaw(path string) ([]byte, error) {
-	data, err := m.cdb.Get(s2b(path))
+	return m.genRaw(m.gen.Load(), path)
+}
+
+// genRaw reads the generation, which must be pinned or protected from reloads by m.mutex.
+// Data returned is copied from the file, so it remains valid after the generation is unmapped.
+func (m *Module) genRaw(gen *generation, path string) ([]byte, error) {
+	if gen == nil {
+		return nil, ErrClosed
+	}
+
+	data, err := gen.cdb.Get(s2b(path))
 	if err != nil {
-		return nil, fmt.Errorf("cdb.Get(%s:%s): %w", m.filename, path, err)
+		return nil, fmt.Errorf("cdb.Get(%s:%s): %w", m.location(), path, err)
 	}
 
 	return data, nil
 }
 
+// location returns the module file name or the name of an in-memory module.
+func (m *Module) location() string {
+	if m.filename == "" {
+		return m.name
+	}
+
+	return m.filename
+}
+
 // Path returns its argument.
 func (*Module) Path(path string) string {
 	return path
@@ -203,6 +483,13 @@ func (*Module) Path(path string) string {
 // If no such value exists, [ErrNotFound] is returned.
 // If the value is not a string, [ErrFormatIsNotString] is returned.
 func (m *Module) GetStringErr(path string) (string, error) {
+	str, err := m.getString(path)
+	m.countGet("string", err)
+
+	return str, err
+}
+
+func (m *Module) getString(path string) (string, error) {
 	switch format, data, err := m.get(path); {
 	case err != nil:
 		return "", err
@@ -222,18 +509,18 @@ func (m *Module) GetStringErr(path string) (string, error) {
 //
 // CDB errors and format mismatches are logged.
 func (m *Module) GetStringIfExists(path string) (string, bool) {
-	switch format, data, err := m.get(path); {
-	case err != nil:
-		log.Print(err)
-		return "", false
-	case format == 0:
-		return "", false
-	case format == 's':
-		return b2s(data), true
-	default:
-		log.Printf("%s:%s: %v", m.name, path, ErrFormatIsNotString)
+	str, err := m.getString(path)
+	m.countGet("string", err)
+
+	if err != nil {
+		if err != ErrNotFound {
+			m.logGetError(path, "string", err)
+		}
+
 		return "", false
 	}
+
+	return str, true
 }
 
 // GetString reads a string value of a named parameter from the module.
@@ -257,14 +544,21 @@ func (m *Module) GetString(path string, dfl string) string {
 // If the value is not a string, [ErrFormatIsNotString] is returned.
 // If the value doesn't represent a valid int (see [strconv.Atoi]), a wrapped parsing error is returned.
 func (m *Module) GetIntErr(path string) (int, error) {
-	str, err := m.GetStringErr(path)
+	val, err := m.getInt(path)
+	m.countGet("int", err)
+
+	return val, err
+}
+
+func (m *Module) getInt(path string) (int, error) {
+	str, err := m.getString(path)
 	if err != nil {
 		return 0, err
 	}
 
 	i, err := strconv.Atoi(str)
 	if err != nil {
-		return 0, fmt.Errorf("%s:%s: %w", m.name, path, err)
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
 	}
 
 	return i, nil
@@ -280,10 +574,12 @@ func (m *Module) GetIntErr(path string) (int, error) {
 //
 // CDB errors, format mismatches, and parsing errors are logged.
 func (m *Module) GetIntIfExists(path string) (int, bool) {
-	i, err := m.GetIntErr(path)
+	i, err := m.getInt(path)
+	m.countGet("int", err)
+
 	if err != nil {
 		if err != ErrNotFound { // ErrNotFound is returned unwrapped
-			log.Print(err)
+			m.logGetError(path, "int", err)
 		}
 
 		return 0, false
@@ -304,21 +600,205 @@ func (m *Module) GetInt(path string, dfl int) int {
 	return dfl
 }
 
+// GetInt64Err reads an int64 value of a named parameter from the module.
+//
+// If no such value exists, [ErrNotFound] is returned.
+// If the value is not a string, [ErrFormatIsNotString] is returned.
+// If the value doesn't represent a valid string representation of an int64 value (see [strconv.ParseInt]), a wrapped parsing error is returned.
+func (m *Module) GetInt64Err(path string) (int64, error) {
+	val, err := m.getInt64(path)
+	m.countGet("int64", err)
+
+	return val, err
+}
+
+func (m *Module) getInt64(path string) (int64, error) {
+	str, err := m.getString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
+	}
+
+	return val, nil
+}
+
+// GetInt64IfExists reads an int64 value of a named parameter from the module.
+//
+// It returns this value and the boolean true if the parameter exists and is
+// a valid string representation of an int64 value (see [strconv.ParseInt]).
+// In the other case, it returns the boolean false and 0.
+//
+// CDB errors, format mismatches, and parsing errors are logged.
+func (m *Module) GetInt64IfExists(path string) (int64, bool) {
+	val, err := m.getInt64(path)
+	m.countGet("int64", err)
+
+	if err != nil {
+		if err != ErrNotFound { // ErrNotFound is returned unwrapped
+			m.logGetError(path, "int64", err)
+		}
+
+		return 0, false
+	}
+
+	return val, true
+}
+
+// GetInt64 reads an int64 value of a named parameter from the module.
+// Calls [Module.GetInt64IfExists] internally. The default value `dfl` is returned
+// when [Module.GetInt64IfExists] returns (0, false).
+func (m *Module) GetInt64(path string, dfl int64) int64 {
+	if val, ok := m.GetInt64IfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetUint64Err reads a uint64 value of a named parameter from the module.
+//
+// If no such value exists, [ErrNotFound] is returned.
+// If the value is not a string, [ErrFormatIsNotString] is returned.
+// If the value doesn't represent a valid string representation of a uint64 value (see [strconv.ParseUint]), a wrapped parsing error is returned.
+func (m *Module) GetUint64Err(path string) (uint64, error) {
+	val, err := m.getUint64(path)
+	m.countGet("uint64", err)
+
+	return val, err
+}
+
+func (m *Module) getUint64(path string) (uint64, error) {
+	str, err := m.getString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
+	}
+
+	return val, nil
+}
+
+// GetUint64IfExists reads a uint64 value of a named parameter from the module.
+//
+// It returns this value and the boolean true if the parameter exists and is
+// a valid string representation of a uint64 value (see [strconv.ParseUint]).
+// In the other case, it returns the boolean false and 0.
+//
+// CDB errors, format mismatches, and parsing errors are logged.
+func (m *Module) GetUint64IfExists(path string) (uint64, bool) {
+	val, err := m.getUint64(path)
+	m.countGet("uint64", err)
+
+	if err != nil {
+		if err != ErrNotFound { // ErrNotFound is returned unwrapped
+			m.logGetError(path, "uint64", err)
+		}
+
+		return 0, false
+	}
+
+	return val, true
+}
+
+// GetUint64 reads a uint64 value of a named parameter from the module.
+// Calls [Module.GetUint64IfExists] internally. The default value `dfl` is returned
+// when [Module.GetUint64IfExists] returns (0, false).
+func (m *Module) GetUint64(path string, dfl uint64) uint64 {
+	if val, ok := m.GetUint64IfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetByteSizeErr reads a byte size value of a named parameter from the module.
+//
+// If no such value exists, [ErrNotFound] is returned.
+// If the value is not a string, [ErrFormatIsNotString] is returned.
+// If the value doesn't represent a valid byte size (see [ParseByteSize]), e.g. "512MiB" or "10k", a wrapped parsing error is returned.
+func (m *Module) GetByteSizeErr(path string) (uint64, error) {
+	val, err := m.getByteSize(path)
+	m.countGet("bytesize", err)
+
+	return val, err
+}
+
+func (m *Module) getByteSize(path string) (uint64, error) {
+	str, err := m.getString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := ParseByteSize(str)
+	if err != nil {
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
+	}
+
+	return val, nil
+}
+
+// GetByteSizeIfExists reads a byte size value of a named parameter from the module.
+//
+// It returns this value and the boolean true if the parameter exists and is
+// a valid byte size (see [ParseByteSize]), e.g. "512MiB" or "10k".
+// In the other case, it returns the boolean false and 0.
+//
+// CDB errors, format mismatches, and parsing errors are logged.
+func (m *Module) GetByteSizeIfExists(path string) (uint64, bool) {
+	val, err := m.getByteSize(path)
+	m.countGet("bytesize", err)
+
+	if err != nil {
+		if err != ErrNotFound { // ErrNotFound is returned unwrapped
+			m.logGetError(path, "bytesize", err)
+		}
+
+		return 0, false
+	}
+
+	return val, true
+}
+
+// GetByteSize reads a byte size value of a named parameter from the module.
+// Calls [Module.GetByteSizeIfExists] internally. The default value `dfl` is returned
+// when [Module.GetByteSizeIfExists] returns (0, false).
+func (m *Module) GetByteSize(path string, dfl uint64) uint64 {
+	if val, ok := m.GetByteSizeIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
 // GetBoolErr reads a boolean value of a named parameter from the module.
 //
 // false is returned when the value exists and is empty of "0", true is
 // returned when the value exists but is neither empty nor "0".
 func (m *Module) GetBoolErr(path string) (bool, error) {
-	str, err := m.GetStringErr(path)
+	val, err := m.getBool(path)
+	m.countGet("bool", err)
+
+	return val, err
+}
+
+func (m *Module) getBool(path string) (bool, error) {
+	str, err := m.getString(path)
 	if err != nil {
 		return false, err
 	}
 
-	if len(str) == 0 || str == "0" { // preserve compatibility with mature perl projects. please do not add new values.
-		return false, nil
-	}
+	return parseBool(str), nil
+}
 
-	return true, nil
+func parseBool(s string) bool {
+	return len(s) != 0 && s != "0" // preserve compatibility with mature perl projects. please do not add new values.
 }
 
 // GetBoolIfExists reads an integer value of a named parameter from the module.
@@ -329,10 +809,12 @@ func (m *Module) GetBoolErr(path string) (bool, error) {
 //
 // CDB errors and format mismatches are logged.
 func (m *Module) GetBoolIfExists(path string) (bool, bool) {
-	b, err := m.GetBoolErr(path)
+	b, err := m.getBool(path)
+	m.countGet("bool", err)
+
 	if err != nil {
 		if err != ErrNotFound {
-			log.Print(err)
+			m.logGetError(path, "bool", err)
 		}
 
 		return false, false
@@ -360,14 +842,21 @@ func (m *Module) GetBool(path string, dfl bool) bool {
 // For compatibility with other implementations, when no unit suffix is specified,
 // a value is treated as a duration in seconds.
 func (m *Module) GetDurationErr(path string) (time.Duration, error) {
-	str, err := m.GetStringErr(path)
+	val, err := m.getDuration(path)
+	m.countGet("duration", err)
+
+	return val, err
+}
+
+func (m *Module) getDuration(path string) (time.Duration, error) {
+	str, err := m.getString(path)
 	if err != nil {
 		return 0, err
 	}
 
 	d, err := parseDuration(str)
 	if err != nil {
-		return 0, fmt.Errorf("%s:%s: %w", m.name, path, err)
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
 	}
 
 	return d, nil
@@ -386,10 +875,12 @@ func parseDuration(s string) (time.Duration, error) {
 // Calls [Module.GetDurationErr] internally. In the case of an error (0, false) is returned.
 // Errors other than [ErrNotFound] are logged.
 func (m *Module) GetDurationIfExists(path string) (time.Duration, bool) {
-	d, err := m.GetDurationErr(path)
+	d, err := m.getDuration(path)
+	m.countGet("duration", err)
+
 	if err != nil {
 		if err != ErrNotFound {
-			log.Print(err)
+			m.logGetError(path, "duration", err)
 		}
 
 		return 0, false
@@ -424,14 +915,21 @@ func (m *Module) GetDuration(path string, dfl time.Duration) time.Duration {
 // If the value doesn't represent a valid float64 (see [strconv.ParseFloat]),
 // a wrapped parsing error is returned.
 func (m *Module) GetFloatErr(path string) (float64, error) {
-	str, err := m.GetStringErr(path)
+	val, err := m.getFloat(path)
+	m.countGet("float", err)
+
+	return val, err
+}
+
+func (m *Module) getFloat(path string) (float64, error) {
+	str, err := m.getString(path)
 	if err != nil {
 		return 0, err
 	}
 
 	f, err := strconv.ParseFloat(str, 64)
 	if err != nil {
-		return 0, fmt.Errorf("%s:%s: %w", m.name, path, err)
+		return 0, parseError{fmt.Errorf("%s:%s: %w", m.name, path, err)}
 	}
 
 	return f, nil
@@ -446,10 +944,12 @@ func (m *Module) GetFloatErr(path string) (float64, error) {
 //
 // CDB errors, format mismatches, and parsing errors are logged.
 func (m *Module) GetFloatIfExists(path string) (float64, bool) {
-	f, err := m.GetFloatErr(path)
+	f, err := m.getFloat(path)
+	m.countGet("float", err)
+
 	if err != nil {
 		if err != ErrNotFound {
-			log.Print(err)
+			m.logGetError(path, "float", err)
 		}
 
 		return 0, false
@@ -477,14 +977,21 @@ func (m *Module) GetFloat(path string, dfl float64) float64 {
 //
 // Strings returned are cached internally until the configuration is updated.
 func (m *Module) GetStringsErr(path string, dfl []string) ([]string, error) {
+	ret, err := m.getStrings(path, dfl)
+	m.countGet("strings", err)
+
+	return ret, err
+}
+
+func (m *Module) getStrings(path string, dfl []string) ([]string, error) {
 	var ret []string
 
 	rv := reflect.ValueOf(&ret).Elem()
-	if m.cache.get(path, rv) {
+	if m.cacheGet(path, rv) {
 		return ret, nil
 	}
 
-	format, data, err := m.get(path)
+	format, data, cache, err := m.lookup(path)
 	if err != nil {
 		return dfl, err
 	}
@@ -493,24 +1000,16 @@ func (m *Module) GetStringsErr(path string, dfl []string) ([]string, error) {
 	case 0:
 		return dfl, ErrNotFound
 	case 's':
-		items := strings.Split(b2s(data), ",")
-		ret = make([]string, 0, len(items))
-
-		for _, item := range items {
-			if trimmed := strings.TrimSpace(item); trimmed != "" {
-				ret = append(ret, trimmed)
-			}
-		}
-
-		m.cache.set(path, rv)
+		ret = splitStrings(b2s(data))
+		cache.set(path, rv)
 
 		return ret, nil
 	case 'j':
 		if err := json.Unmarshal(data, &ret); err != nil {
-			return dfl, fmt.Errorf("%s:%s: failed to unmarshal JSON: %w", m.name, path, err)
+			return dfl, parseError{fmt.Errorf("%s:%s: failed to unmarshal JSON: %w", m.name, path, err)}
 		}
 
-		m.cache.set(path, rv)
+		cache.set(path, rv)
 
 		return ret, nil
 
@@ -522,7 +1021,13 @@ func (m *Module) GetStringsErr(path string, dfl []string) ([]string, error) {
 // getStringsRaw is used internally for recursive subscriptions.
 // value cache isn't used. Never returns ErrNotFound.
 func (m *Module) getStringsRaw(path string) ([]string, error) {
-	data, err := m.getRaw(path)
+	return m.genStringsRaw(m.gen.Load(), path)
+}
+
+// genStringsRaw reads a JSON list of strings, e.g. a child list, from the generation bypassing
+// environment overrides, the value cache and metrics. See [Module.genRaw] for requirements.
+func (m *Module) genStringsRaw(gen *generation, path string) ([]string, error) {
+	data, err := m.genRaw(gen, path)
 	if err != nil {
 		return nil, err
 	}
@@ -547,10 +1052,12 @@ func (m *Module) getStringsRaw(path string) ([]string, error) {
 // GetStrings reads a []string value of a named parameter from the module.
 // Calls [Module.GetStringsErr] internally. All errors but [ErrNotFound] are logged.
 func (m *Module) GetStrings(path string, dfl []string) []string {
-	ret, err := m.GetStringsErr(path, dfl)
+	ret, err := m.getStrings(path, dfl)
+	m.countGet("strings", err)
+
 	if err != nil {
 		if err != ErrNotFound {
-			log.Print(err)
+			m.logGetError(path, "strings", err)
 		}
 
 		return dfl
@@ -573,17 +1080,32 @@ func (m *Module) GetStrings(path string, dfl []string) []string {
 //
 // Never returns ErrNotFound.
 func (m *Module) GetStruct(path string, valuePtr interface{}) (bool, error) {
+	ok, err := m.getStruct(path, valuePtr)
+
+	switch {
+	case err != nil:
+		m.countGet("struct", err)
+	case !ok:
+		m.countGet("struct", ErrNotFound)
+	default:
+		m.countGet("struct", nil)
+	}
+
+	return ok, err
+}
+
+func (m *Module) getStruct(path string, valuePtr interface{}) (bool, error) {
 	rv := reflect.ValueOf(valuePtr)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return false, fmt.Errorf("%s:%s: GetStruct accepts a non-nil pointer", m.name, path)
 	}
 
 	rv = rv.Elem()
-	if m.cache.get(path, rv) {
+	if m.cacheGet(path, rv) {
 		return true, nil
 	}
 
-	format, data, err := m.get(path)
+	format, data, cache, err := m.lookup(path)
 	if err != nil {
 		return false, err
 	}
@@ -594,11 +1116,11 @@ func (m *Module) GetStruct(path string, valuePtr interface{}) (bool, error) {
 	case 'j':
 		val := reflect.New(rv.Type()) // ensure that the default value isn't clobbered by a partially failed unmarshal
 		if err := json.Unmarshal(data, val.Interface()); err != nil {
-			return false, fmt.Errorf("%s:%s: failed to unmarshal JSON: %w", m.name, path, err)
+			return false, parseError{fmt.Errorf("%s:%s: failed to unmarshal JSON: %w", m.name, path, err)}
 		}
 
 		rv.Set(val.Elem())
-		m.cache.set(path, rv)
+		cache.set(path, rv)
 
 		return true, nil
 	default:
@@ -0,0 +1,206 @@
+package onlineconf
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/colinmarc/cdb"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+)
+
+var benchTree = map[string]any{
+	"/bench/string": "value",
+	"/bench/int":    12345,
+	"/bench/struct": json.RawMessage(`{"host": "localhost", "port": 5432}`),
+}
+
+type benchStruct struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+func newBenchModule(b *testing.B) *Module {
+	mod, err := NewModuleFromMap("bench", benchTree)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() { mod.Close() })
+
+	return mod
+}
+
+func BenchmarkGetString(b *testing.B) {
+	mod := newBenchModule(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mod.GetString("/bench/string", "")
+		}
+	})
+}
+
+func BenchmarkGetStruct(b *testing.B) {
+	mod := newBenchModule(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		var v benchStruct
+
+		for pb.Next() {
+			if _, err := mod.GetStruct("/bench/struct", &v); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// rwMutexReader is the read path of modules before generations were introduced: the current CDB
+// is guarded by an RWMutex held for the duration of every read. It's a baseline for BenchmarkRead.
+type rwMutexReader struct {
+	mutex sync.RWMutex
+	cdb   *cdb.CDB
+}
+
+func (r *rwMutexReader) get(path string) ([]byte, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cdb.Get(s2b(path))
+}
+
+// BenchmarkRead compares raw reads guarded by an RWMutex with reads of a pinned generation:
+//
+//	go test -run '^$' -bench '^BenchmarkRead$' -cpu 1,4,16 -count 10 > read.txt
+//	benchstat -col /impl read.txt
+func BenchmarkRead(b *testing.B) {
+	dir := b.TempDir()
+	cdbName := filepath.Join(dir, "bench.cdb")
+
+	if err := writeBenchCDB(dir, cdbName, 0); err != nil {
+		b.Fatal(err)
+	}
+
+	mod, err := OpenModule(cdbName) // mapped, so readers are counted
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer mod.Close()
+
+	baseline := &rwMutexReader{cdb: mod.gen.Load().cdb}
+
+	b.Run("impl=rwmutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := baseline.get("/bench/string"); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("impl=generation", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				gen, shard := mod.pin()
+				_, err := mod.genRaw(gen, "/bench/string")
+				mod.unpin(gen, shard)
+
+				if err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkGetStringDuringReload measures reads while the module file is constantly reloaded.
+func BenchmarkGetStringDuringReload(b *testing.B) {
+	dir := b.TempDir()
+	cdbName := filepath.Join(dir, "bench.cdb")
+
+	if err := writeBenchCDB(dir, cdbName, 0); err != nil {
+		b.Fatal(err)
+	}
+
+	mod, err := OpenModule(cdbName)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer mod.Close()
+
+	mod.SetLogger(slog.New(slog.DiscardHandler)) // every reload is logged
+
+	done := make(chan struct{})
+	reloaded := make(chan struct{})
+	reloadErr := make(chan error, 1)
+
+	go func() {
+		defer close(reloaded)
+
+		for i := 1; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			err := writeBenchCDB(dir, cdbName, i)
+			if err == nil {
+				err = mod.Reload()
+			}
+
+			if err != nil {
+				reloadErr <- err
+				return
+			}
+		}
+	}()
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mod.GetString("/bench/string", "")
+		}
+	})
+
+	b.StopTimer()
+	close(done)
+	<-reloaded
+
+	select {
+	case err := <-reloadErr:
+		b.Fatal(err)
+	default:
+	}
+}
+
+func writeBenchCDB(dir, cdbName string, i int) error {
+	records, err := cdbtree.Flatten(map[string]any{"/bench/string": strconv.Itoa(i)})
+	if err != nil {
+		return err
+	}
+
+	tmpName := filepath.Join(dir, "bench.cdb.tmp")
+
+	f, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close() // closed by cdbtree.Write on success
+
+	if err := cdbtree.Write(f, records); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, cdbName)
+}
@@ -1,7 +1,10 @@
 package onlineconf
 
 import (
+	"errors"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -36,3 +39,129 @@ func TestOpenModuleErrorDoesNotPoisonCache(t *testing.T) {
 		}
 	}
 }
+
+func TestModuleClose(t *testing.T) {
+	cdbName := filepath.Join(t.TempDir(), "close.cdb")
+	writeCDB(t, cdbName, map[string]string{"/key": "value"})
+
+	mod1, err := OpenModule(cdbName)
+	if err != nil {
+		t.Fatalf("OpenModule(%q): %v", cdbName, err)
+	}
+
+	mod2, err := OpenModule(cdbName)
+	if err != nil {
+		t.Fatalf("second OpenModule(%q): %v", cdbName, err)
+	}
+
+	if mod1 != mod2 {
+		t.Fatal("OpenModule must return the cached module")
+	}
+
+	ch, err := mod1.Subscribe("/key")
+	if err != nil {
+		t.Fatal(`Subscribe("/key"):`, err)
+	}
+
+	if err := mod1.Close(); err != nil {
+		t.Fatal("first Close():", err)
+	}
+
+	if got := mod2.GetString("/key", ""); got != "value" {
+		t.Fatalf(`GetString("/key") after the first Close() = %q, want "value"`, got)
+	}
+
+	if err := mod2.Close(); err != nil {
+		t.Fatal("second Close():", err)
+	}
+
+	if _, ok := <-ch; ok {
+		t.Fatal("subscription channel must be closed by the last Close()")
+	}
+
+	if _, err := mod2.GetStringErr("/key"); !errors.Is(err, ErrClosed) {
+		t.Fatalf(`GetStringErr("/key") after the last Close(): got %v, want ErrClosed`, err)
+	}
+
+	if err := mod2.Close(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("extra Close(): got %v, want ErrClosed", err)
+	}
+
+	w, err := initWatcherOnce()
+	if err != nil {
+		t.Fatal("initWatcherOnce():", err)
+	}
+
+	if n := w.dirs[filepath.Dir(cdbName)]; n != 0 {
+		t.Fatalf("the directory is still watched by %d modules", n)
+	}
+
+	mod3, err := OpenModule(cdbName)
+	if err != nil {
+		t.Fatalf("OpenModule(%q) after Close(): %v", cdbName, err)
+	}
+	defer mod3.Close()
+
+	if mod3 == mod1 {
+		t.Fatal("OpenModule must not return a closed module")
+	}
+
+	if got := mod3.GetString("/key", ""); got != "value" {
+		t.Fatalf(`GetString("/key") of the reopened module = %q, want "value"`, got)
+	}
+}
+
+func TestReadDuringReload(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/key": "0"})
+
+	const reloads = 20
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	for range testGoroCount {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			last := 0
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				got, err := mod.GetIntErr("/key")
+				if err != nil {
+					t.Error(`GetIntErr("/key"):`, err)
+					return
+				}
+
+				if got < last || got > reloads {
+					t.Errorf(`GetIntErr("/key") = %d after %d`, got, last)
+					return
+				}
+
+				last = got
+			}
+		}()
+	}
+
+	for i := 1; i <= reloads; i++ {
+		writeCDB(t, cdbName, map[string]string{"/key": strconv.Itoa(i)})
+
+		if err := mod.Reload(); err != nil {
+			t.Fatal("Reload():", err)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	if got := mod.GetInt("/key", 0); got != reloads {
+		t.Fatalf(`GetInt("/key") after all reloads = %d, want %d`, got, reloads)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"strconv"
@@ -13,7 +14,7 @@ import (
 	"time"
 	"unsafe"
 
-	"github.com/colinmarc/cdb"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -49,14 +50,6 @@ func TestOCTestSuite(t *testing.T) {
 	suite.Run(t, new(ocTestSuite))
 }
 
-// initialize writer
-func (suite *ocTestSuite) getCDBWriter() *cdb.Writer {
-	writer, err := cdb.Create(suite.cdbFile.Name())
-	suite.Require().Nilf(err, "Can't get CDB writer: %#v", err)
-
-	return writer
-}
-
 // generate test records
 func generateTestRecords(count int) testRecords {
 	ret := testRecords{
@@ -158,10 +151,11 @@ func (suite *ocTestSuite) SetupTest() {
 }
 
 func (suite *ocTestSuite) TearDownTest() {
+	suite.NoError(suite.module.Close(), "Close() failed")
 	os.Remove(suite.module.filename)
 }
 
-func fillTestCDB(writer *cdb.Writer, testRecords testRecords) error {
+func fillTestCDB(f *os.File, testRecords testRecords) error {
 	allTestRecords := []testCDBRecord{}
 	allTestRecords = append(allTestRecords, testRecords.stringRecords...)
 	allTestRecords = append(allTestRecords, testRecords.intRecords...)
@@ -174,27 +168,18 @@ func fillTestCDB(writer *cdb.Writer, testRecords testRecords) error {
 	allTestRecords = append(allTestRecords, testRecords.partialStruct)
 	allTestRecords = append(allTestRecords, testRecords.invalidStruct)
 
+	records := make(map[string][]byte, len(allTestRecords))
 	for _, rec := range allTestRecords {
-		// log.Printf("putting: key %s val %s", string(rec.key), string(rec.val))
-		err := writer.Put(rec.key, rec.val)
-		if err != nil {
-			return err
-		}
-	}
-
-	if err := writer.Close(); err != nil {
-		return err
+		records[string(rec.key)] = rec.val
 	}
 
-	return nil
+	return cdbtree.Write(f, records)
 }
 
 func (suite *ocTestSuite) prepareTestData() {
 	suite.testRecords = generateTestRecords(5)
 
-	writer := suite.getCDBWriter()
-	err := fillTestCDB(writer, suite.testRecords)
-	suite.Nil(err)
+	err := fillTestCDB(suite.cdbFile, suite.testRecords)
 	suite.Require().Nilf(err, "Cant put new value to cdb: %#v", err)
 }
 
@@ -202,6 +187,7 @@ func (suite *ocTestSuite) TestModCache() {
 	mod2, err := OpenModule(suite.module.filename)
 	suite.NoError(err, "OpenModule() failed")
 	suite.Equal(suite.module, mod2, "module should be cached")
+	suite.NoError(mod2.Close(), "Close() failed")
 }
 
 func (suite *ocTestSuite) TestInt() {
@@ -471,7 +457,27 @@ func (suite *ocTestSuite) TestStruct() {
 }
 
 func (suite *ocTestSuite) TestReload() {
-	// todo
+	ch, err := suite.module.Subscribe("/test/onlineconf/str0")
+	suite.Require().NoError(err, "Subscribe() failed")
+
+	suite.Require().NoError(suite.module.Reload(), "Reload() failed")
+
+	select {
+	case <-ch:
+		suite.Fail("Reload() of the same file must not send notifications")
+	default:
+	}
+
+	writeCDB(suite.T(), suite.module.filename, map[string]string{"/test/onlineconf/str0": "reloaded"})
+	suite.Require().NoError(suite.module.Reload(), "Reload() failed")
+
+	select {
+	case <-ch:
+	default:
+		suite.Fail("Reload() must send notifications synchronously")
+	}
+
+	suite.Equal("reloaded", suite.module.GetString("/test/onlineconf/str0", ""))
 }
 
 func (suite *ocTestSuite) TestConcurrent() {
@@ -524,6 +530,10 @@ type onlineconfIface interface {
 	GetFloat(path string, dfl float64) float64
 	GetStrings(path string, dfl []string) []string
 	GetStruct(path string, valuePtr interface{}) (bool, error)
+	Bind(ptr any) error
+	All() iter.Seq2[string, Value]
+	Walk(path string, fn WalkFunc) error
+	Children(path string) ([]string, error)
 	Subtree(prefix string) *Subtree
 	SubscribeChan(path string, ch chan<- struct{}) error
 	Subscribe(path string) (chan struct{}, error)
@@ -533,6 +543,12 @@ type onlineconfIface interface {
 	UnsubscribeChanSubtree(path string, ch chan<- struct{})
 	Unsubscribe(path string)
 	UnsubscribeSubtree(path string)
+	SubscribeEventsChan(path string, ch chan<- Event) error
+	SubscribeEvents(path string) (chan Event, error)
+	SubscribeEventsChanSubtree(path string, ch chan<- Event) error
+	SubscribeEventsSubtree(path string) (chan Event, error)
+	UnsubscribeEventsChan(path string, ch chan<- Event)
+	UnsubscribeEventsChanSubtree(path string, ch chan<- Event)
 }
 
 var (
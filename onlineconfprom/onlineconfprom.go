@@ -0,0 +1,295 @@
+// Package onlineconfprom implements [onlineconf.Metrics] exposing the metrics
+// in the Prometheus text exposition format without any external dependencies.
+//
+// Usage:
+//
+//	metrics := onlineconfprom.New()
+//	onlineconf.SetMetrics(metrics)
+//	http.Handle("/metrics/onlineconf", metrics)
+//
+// The following metrics are exposed:
+//
+//	onlineconf_get_total{module,type,result}          counter
+//	onlineconf_cache_lookups_total{module,result}     counter, result is "hit" or "miss"
+//	onlineconf_reloads_total{module}                  counter
+//	onlineconf_reload_failures_total{module}          counter
+//	onlineconf_reload_duration_seconds{module}        histogram
+//	onlineconf_subscriptions{module}                  gauge
+//	onlineconf_dropped_notifications_total{module}    counter
+//
+// Use [Metrics.WriteTo] to append the metrics to an existing exposition.
+package onlineconfprom
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/onlineconf/onlineconf-go/v2"
+)
+
+// reloadBuckets are upper bounds of the reload duration histogram buckets in seconds.
+var reloadBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+type getKey struct {
+	module string
+	typ    string
+	result onlineconf.GetResult
+}
+
+type cacheKey struct {
+	module string
+	hit    bool
+}
+
+type reloadStats struct {
+	count    uint64
+	failures uint64
+	buckets  []uint64 // cumulative counts are computed on output
+	sum      float64
+}
+
+// Metrics collects metrics of the onlineconf package. It implements [onlineconf.Metrics] and [http.Handler].
+// Counters of getters and caches are lock-free. The zero value isn't usable, use [New].
+type Metrics struct {
+	gets    sync.Map // getKey -> *atomic.Uint64
+	cache   sync.Map // cacheKey -> *atomic.Uint64
+	dropped sync.Map // module -> *atomic.Uint64
+
+	mutex         sync.Mutex
+	reloads       map[string]*reloadStats
+	subscriptions map[string]int
+}
+
+var _ onlineconf.Metrics = (*Metrics)(nil)
+
+// New creates an empty metrics collector.
+func New() *Metrics {
+	return &Metrics{
+		reloads:       make(map[string]*reloadStats),
+		subscriptions: make(map[string]int),
+	}
+}
+
+func counter[K comparable](m *sync.Map, key K) *atomic.Uint64 {
+	if c, ok := m.Load(key); ok {
+		return c.(*atomic.Uint64)
+	}
+
+	c, _ := m.LoadOrStore(key, new(atomic.Uint64))
+
+	return c.(*atomic.Uint64)
+}
+
+// Get implements [onlineconf.Metrics].
+func (m *Metrics) Get(module, typ string, result onlineconf.GetResult) {
+	counter(&m.gets, getKey{module: module, typ: typ, result: result}).Add(1)
+}
+
+// Cache implements [onlineconf.Metrics].
+func (m *Metrics) Cache(module string, hit bool) {
+	counter(&m.cache, cacheKey{module: module, hit: hit}).Add(1)
+}
+
+// Reload implements [onlineconf.Metrics].
+func (m *Metrics) Reload(module string, duration time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats, ok := m.reloads[module]
+	if !ok {
+		stats = &reloadStats{buckets: make([]uint64, len(reloadBuckets))}
+		m.reloads[module] = stats
+	}
+
+	stats.count++
+
+	if err != nil {
+		stats.failures++
+	}
+
+	seconds := duration.Seconds()
+	stats.sum += seconds
+
+	if i, _ := slices.BinarySearch(reloadBuckets, seconds); i < len(reloadBuckets) {
+		stats.buckets[i]++
+	}
+}
+
+// Subscriptions implements [onlineconf.Metrics].
+func (m *Metrics) Subscriptions(module string, n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.subscriptions[module] = n
+}
+
+// NotificationDropped implements [onlineconf.Metrics].
+func (m *Metrics) NotificationDropped(module string) {
+	counter(&m.dropped, module).Add(1)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+type sample struct {
+	labels string
+	value  string
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// Samples are ordered by labels, so the output is stable.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var (
+		buf     bytes.Buffer
+		samples []sample
+	)
+
+	m.gets.Range(func(k, v any) bool {
+		key := k.(getKey)
+		samples = append(samples, sample{
+			labels: labels("module", key.module, "type", key.typ, "result", key.result.String()),
+			value:  strconv.FormatUint(v.(*atomic.Uint64).Load(), 10),
+		})
+
+		return true
+	})
+	writeFamily(&buf, "onlineconf_get_total", "counter", "Number of GetXXX calls by module, value type and result.", samples)
+
+	samples = samples[:0]
+	m.cache.Range(func(k, v any) bool {
+		key := k.(cacheKey)
+		result := "miss"
+
+		if key.hit {
+			result = "hit"
+		}
+
+		samples = append(samples, sample{
+			labels: labels("module", key.module, "result", result),
+			value:  strconv.FormatUint(v.(*atomic.Uint64).Load(), 10),
+		})
+
+		return true
+	})
+	writeFamily(&buf, "onlineconf_cache_lookups_total", "counter", "Number of value cache lookups by module and result.", samples)
+
+	m.writeReloads(&buf)
+
+	m.mutex.Lock()
+	samples = samples[:0]
+	for module, n := range m.subscriptions {
+		samples = append(samples, sample{labels: labels("module", module), value: strconv.Itoa(n)})
+	}
+	m.mutex.Unlock()
+	writeFamily(&buf, "onlineconf_subscriptions", "gauge", "Number of channels subscribed to a module.", samples)
+
+	samples = samples[:0]
+	m.dropped.Range(func(k, v any) bool {
+		samples = append(samples, sample{
+			labels: labels("module", k.(string)),
+			value:  strconv.FormatUint(v.(*atomic.Uint64).Load(), 10),
+		})
+
+		return true
+	})
+	writeFamily(&buf, "onlineconf_dropped_notifications_total", "counter",
+		"Number of notifications not sent since subscribed channels were busy.", samples)
+
+	return buf.WriteTo(w)
+}
+
+func (m *Metrics) writeReloads(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	modules := make([]string, 0, len(m.reloads))
+	for module := range m.reloads {
+		modules = append(modules, module)
+	}
+
+	slices.Sort(modules)
+
+	var counts, failures []sample
+
+	for _, module := range modules {
+		stats := m.reloads[module]
+		counts = append(counts, sample{labels: labels("module", module), value: strconv.FormatUint(stats.count, 10)})
+		failures = append(failures, sample{labels: labels("module", module), value: strconv.FormatUint(stats.failures, 10)})
+	}
+
+	writeFamily(w, "onlineconf_reloads_total", "counter", "Number of attempts to load a new version of a module file.", counts)
+	writeFamily(w, "onlineconf_reload_failures_total", "counter", "Number of failed attempts to load a new version of a module file.", failures)
+
+	const name = "onlineconf_reload_duration_seconds"
+
+	fmt.Fprintf(w, "# HELP %s Duration of loading of new versions of module files.\n", name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+
+	for _, module := range modules {
+		stats := m.reloads[module]
+
+		var cumulative uint64
+
+		for i, le := range reloadBuckets {
+			cumulative += stats.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("module", module, "le", formatFloat(le)), cumulative)
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("module", module, "le", "+Inf"), stats.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labels("module", module), formatFloat(stats.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels("module", module), stats.count)
+	}
+}
+
+func writeFamily(w io.Writer, name, typ, help string, samples []sample) {
+	slices.SortFunc(samples, func(a, b sample) int {
+		return cmp.Compare(a.labels, b.labels)
+	})
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", name, s.labels, s.value)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats label name-value pairs.
+func labels(pairs ...string) string {
+	var b strings.Builder
+
+	b.WriteByte('{')
+
+	for i := 0; i < len(pairs); i += 2 {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		_, _ = labelValueReplacer.WriteString(&b, pairs[i+1]) // strings.Builder never fails
+		b.WriteByte('"')
+	}
+
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
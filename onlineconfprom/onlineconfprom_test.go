@@ -0,0 +1,85 @@
+package onlineconfprom_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlineconf/onlineconf-go/v2"
+	"github.com/onlineconf/onlineconf-go/v2/onlineconfprom"
+	"github.com/onlineconf/onlineconf-go/v2/onlineconftest"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := onlineconfprom.New()
+
+	onlineconf.SetMetrics(metrics)
+	defer onlineconf.SetMetrics(nil)
+
+	mod := onlineconftest.Open(t, map[string]any{
+		"/int":    "1",
+		"/bad":    "x",
+		"/struct": json.RawMessage(`{}`),
+	})
+
+	mod.GetInt("/int", 0)
+	mod.GetInt("/int", 0)
+	mod.GetInt("/bad", 0)
+	mod.GetInt("/none", 0)
+	mod.GetString("/struct", "")
+	onlineconf.Get(mod, "/int", time.Duration(0))
+
+	var v struct{}
+
+	mod.GetStruct("/struct", &v)
+	mod.GetStruct("/struct", &v)
+
+	ch := make(chan struct{}) // always busy
+	if err := mod.SubscribeChan("/int", ch); err != nil {
+		t.Fatal(`SubscribeChan("/int"):`, err)
+	}
+
+	mod.Replace(map[string]any{"/int": "2"})
+
+	metrics.Reload("bad\"module\n", time.Second, errors.New("failed"))
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	got := rec.Body.String()
+	module := `module="` + mod.Filename() + `"`
+
+	for _, want := range []string{
+		"# TYPE onlineconf_get_total counter\n",
+		`onlineconf_get_total{` + module + `,type="int",result="hit"} 2` + "\n",
+		`onlineconf_get_total{` + module + `,type="int",result="parse_error"} 1` + "\n",
+		`onlineconf_get_total{` + module + `,type="int",result="notfound"} 1` + "\n",
+		`onlineconf_get_total{` + module + `,type="string",result="format_error"} 1` + "\n",
+		`onlineconf_get_total{` + module + `,type="duration",result="hit"} 1` + "\n",
+		`onlineconf_get_total{` + module + `,type="struct",result="hit"} 2` + "\n",
+		`onlineconf_cache_lookups_total{` + module + `,result="hit"} 1` + "\n",
+		`onlineconf_cache_lookups_total{` + module + `,result="miss"} 2` + "\n",
+		`onlineconf_reloads_total{` + module + `} 2` + "\n",
+		`onlineconf_reload_failures_total{` + module + `} 0` + "\n",
+		`onlineconf_reload_failures_total{module="bad\"module\n"} 1` + "\n",
+		"# TYPE onlineconf_reload_duration_seconds histogram\n",
+		`onlineconf_reload_duration_seconds_bucket{module="bad\"module\n",le="0.5"} 0` + "\n",
+		`onlineconf_reload_duration_seconds_bucket{module="bad\"module\n",le="1"} 1` + "\n",
+		`onlineconf_reload_duration_seconds_bucket{module="bad\"module\n",le="+Inf"} 1` + "\n",
+		`onlineconf_reload_duration_seconds_sum{module="bad\"module\n"} 1` + "\n",
+		`onlineconf_reload_duration_seconds_count{` + module + `} 2` + "\n",
+		`onlineconf_subscriptions{` + module + `} 1` + "\n",
+		`onlineconf_dropped_notifications_total{` + module + `} 1` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("no %q in the output:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package onlineconftest provides utilities for testing code reading OnlineConf modules.
+//
+// Trees passed to the functions of this package are maps with string keys.
+// Keys are joined with the parent path, so both nested trees and flat maps of full paths are accepted.
+// Nested maps with string keys are subtrees; strings, numbers, booleans, and [time.Duration] values
+// are stored as text values; [encoding/json.RawMessage] and all other values are stored as JSON values.
+// Child lists (the `child_lists` OnlineConf feature) are generated automatically.
+package onlineconftest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onlineconf/onlineconf-go/v2"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
+)
+
+// WriteFile writes an OnlineConf-compatible CDB file containing the tree.
+//
+// The file is replaced atomically: the data is written to a temporary file in the same directory,
+// which is renamed to filename then.
+func WriteFile(filename string, tree map[string]any) error {
+	records, err := cdbtree.Flatten(tree)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+
+	tmpName := f.Name()
+	defer os.Remove(tmpName) // fails after a successful rename
+
+	if err := cdbtree.Write(f, records); err != nil { // closes f on success
+		f.Close()
+		return fmt.Errorf("%s: %w", tmpName, err)
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("os.Rename(%s, %s): %w", tmpName, filename, err)
+	}
+
+	return nil
+}
+
+// Module is a module opened from a temporary CDB file.
+type Module struct {
+	*onlineconf.Module
+
+	tb       testing.TB
+	filename string
+}
+
+// Open writes the tree to a temporary file and opens it using [onlineconf.OpenModule].
+// The module is closed and the file is removed when the test and all its subtests complete.
+func Open(tb testing.TB, tree map[string]any) *Module {
+	tb.Helper()
+
+	filename := filepath.Join(tb.TempDir(), "TREE.cdb")
+
+	if err := WriteFile(filename, tree); err != nil {
+		tb.Fatalf("onlineconftest.WriteFile(%s): %v", filename, err)
+	}
+
+	mod, err := onlineconf.OpenModule(filename)
+	if err != nil {
+		tb.Fatalf("onlineconf.OpenModule(%s): %v", filename, err)
+	}
+
+	tb.Cleanup(func() {
+		_ = mod.Close()
+	})
+
+	return &Module{
+		Module:   mod,
+		tb:       tb,
+		filename: filename,
+	}
+}
+
+// OpenDefault calls [Open] and makes the module the default one read by package-level functions
+// like [onlineconf.GetString] (see [onlineconf.SetDefaultModule]) until the test completes,
+// then the previous default module is restored.
+// Tests calling OpenDefault must not run in parallel.
+func OpenDefault(tb testing.TB, tree map[string]any) *Module {
+	tb.Helper()
+
+	mod := Open(tb, tree)
+	prev := onlineconf.DefaultModule()
+
+	onlineconf.SetDefaultModule(mod.Module)
+	tb.Cleanup(func() {
+		onlineconf.SetDefaultModule(prev)
+	})
+
+	return mod
+}
+
+// Filename returns the full path of the module file.
+func (m *Module) Filename() string {
+	return m.filename
+}
+
+// Replace atomically replaces the module contents with the tree and blocks until
+// the new file is loaded and notifications are sent to all subscribers of changed values.
+//
+// Notifications are sent to subscribed channels without blocking, so a subscriber
+// can check its channel without waiting right after Replace returns.
+func (m *Module) Replace(tree map[string]any) {
+	m.tb.Helper()
+
+	if err := WriteFile(m.filename, tree); err != nil {
+		m.tb.Fatalf("onlineconftest.WriteFile(%s): %v", m.filename, err)
+	}
+
+	if err := m.Reload(); err != nil {
+		m.tb.Fatalf("onlineconf.Module.Reload(%s): %v", m.filename, err)
+	}
+}
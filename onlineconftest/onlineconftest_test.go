@@ -0,0 +1,95 @@
+package onlineconftest_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/onlineconf/onlineconf-go/v2"
+	"github.com/onlineconf/onlineconf-go/v2/onlineconftest"
+)
+
+func TestReplace(t *testing.T) {
+	mod := onlineconftest.Open(t, map[string]any{
+		"service": map[string]any{
+			"host":    "localhost",
+			"timeout": time.Second,
+			"limits":  json.RawMessage(`{"rps":100}`),
+		},
+		"/unchanged": "value",
+	})
+
+	if got := mod.GetString("/service/host", ""); got != "localhost" {
+		t.Fatalf(`GetString("/service/host") = %q, want "localhost"`, got)
+	}
+
+	hostCh, err := mod.Subscribe("/service/host")
+	if err != nil {
+		t.Fatal(`Subscribe("/service/host"):`, err)
+	}
+
+	serviceCh, err := mod.SubscribeSubtree("/service")
+	if err != nil {
+		t.Fatal(`SubscribeSubtree("/service"):`, err)
+	}
+
+	unchangedCh, err := mod.Subscribe("/unchanged")
+	if err != nil {
+		t.Fatal(`Subscribe("/unchanged"):`, err)
+	}
+
+	mod.Replace(map[string]any{
+		"/service/host":    "example.com",
+		"/service/timeout": time.Second,
+		"/service/limits":  json.RawMessage(`{"rps":100}`),
+		"/unchanged":       "value",
+	})
+
+	for name, ch := range map[string]chan struct{}{"/service/host": hostCh, "/service": serviceCh} {
+		select {
+		case <-ch:
+		default:
+			t.Errorf("%s: no notification after Replace", name)
+		}
+	}
+
+	select {
+	case <-unchangedCh:
+		t.Error("/unchanged: unexpected notification")
+	default:
+	}
+
+	if got := mod.GetString("/service/host", ""); got != "example.com" {
+		t.Errorf(`GetString("/service/host") = %q, want "example.com"`, got)
+	}
+
+	if got := mod.GetDuration("/service/timeout", 0); got != time.Second {
+		t.Errorf(`GetDuration("/service/timeout") = %v, want 1s`, got)
+	}
+}
+
+func TestOpenDefault(t *testing.T) {
+	mod := onlineconftest.OpenDefault(t, map[string]any{"/key": "value"})
+
+	if got := onlineconf.GetString("/key", ""); got != "value" {
+		t.Fatalf(`onlineconf.GetString("/key") = %q, want "value"`, got)
+	}
+
+	mod.Replace(map[string]any{"/key": "new"})
+
+	if got := onlineconf.GetString("/key", ""); got != "new" {
+		t.Fatalf(`onlineconf.GetString("/key") = %q, want "new"`, got)
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		onlineconftest.OpenDefault(t, map[string]any{"/key": "nested"})
+
+		if got := onlineconf.GetString("/key", ""); got != "nested" {
+			t.Fatalf(`onlineconf.GetString("/key") = %q, want "nested"`, got)
+		}
+	})
+
+	if got := onlineconf.GetString("/key", ""); got != "new" {
+		t.Fatalf(`onlineconf.GetString("/key") = %q after a nested test, want "new"`, got)
+	}
+}
@@ -0,0 +1,790 @@
+package onlineconf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Overlay is a read view of several layers ([Module], [Subtree], [Snapshot] or another Overlay),
+// e.g. a local override file on top of the "TREE" module on top of embedded defaults:
+//
+//	overlay := onlineconf.NewOverlay(local, onlineconf.GetModule("TREE"), defaults)
+//
+// Every parameter is read from the first layer having it, so a layer shadows values of the following ones,
+// and lists of children are merged across layers. Channels subscribed to an Overlay are subscribed
+// to all the layers, so they are notified of changes of any layer, including shadowed values.
+//
+// Events sent to channels subscribed by [Overlay.SubscribeEventsChan] describe changes of the overlay
+// rather than of the layers: changes of shadowed values aren't reported.
+//
+// Paths are passed to layers as is, so a [Subtree] layer adds its prefix.
+// Overlays don't own their layers and don't close them. Closing a layer module closes
+// the channels subscribed to it, including the channels of overlays.
+type Overlay struct {
+	layers []Source
+	prefix string
+	events *overlayEvents // shared by subtrees of the overlay
+}
+
+// overlayEvents stores channels subscribed to the layers by event subscriptions of an overlay.
+type overlayEvents struct {
+	mutex    sync.Mutex
+	channels map[overlayEventKey]chan struct{}
+}
+
+type overlayEventKey struct {
+	subscriptionKey // with the full path
+	ch              chan<- Event
+}
+
+// NewOverlay returns an overlay of the layers in the order of decreasing priority.
+// An overlay without layers behaves as an empty module: getters return [ErrNotFound] or default values.
+func NewOverlay(layers ...Source) *Overlay {
+	return &Overlay{layers: layers, events: &overlayEvents{}}
+}
+
+// emptyModule is the only layer of overlays without layers.
+var emptyModule = sync.OnceValue(func() *Module {
+	mod, err := NewModuleFromMap("overlay", map[string]string{})
+	if err != nil {
+		panic(err)
+	}
+
+	return mod
+})
+
+// Path returns the path passed to the layers.
+func (o *Overlay) Path(path string) string {
+	return o.prefix + path
+}
+
+// read reads the parameter from the first layer having it or failing to read it.
+// If no layer has it, the module of the last layer is returned.
+func (o *Overlay) read(path string, rv reflect.Value) (*Module, string, error) {
+	var (
+		m   *Module
+		p   string
+		err error
+	)
+
+	for _, ref := range o.modules(path) {
+		if m, p, err = ref.mod.read(ref.path, rv); err != ErrNotFound {
+			break
+		}
+	}
+
+	return m, p, err
+}
+
+// modules returns modules of all the layers including layers of nested overlays with full paths in them.
+func (o *Overlay) modules(path string) []moduleRef {
+	path = o.prefix + path
+
+	if len(o.layers) == 0 {
+		return []moduleRef{{mod: emptyModule(), path: path}}
+	}
+
+	refs := make([]moduleRef, 0, len(o.layers))
+	for _, layer := range o.layers {
+		refs = append(refs, layer.modules(path)...)
+	}
+
+	return refs
+}
+
+func (o *Overlay) subtree(prefix string) Source {
+	return o.Subtree(prefix)
+}
+
+// Subtree returns a subtree of the overlay. Prefixes are concatenated using [path.Join].
+func (o *Overlay) Subtree(prefix string) *Overlay {
+	return &Overlay{
+		layers: o.layers,
+		prefix: cleanPrefix(path.Join(o.prefix, prefix)),
+		events: o.events,
+	}
+}
+
+// Children returns names of the children of the path merged across the layers, see [Module.Children].
+// Children of the first layer go first, names of the following layers are appended if they are new.
+//
+// Layers without the path or without child lists are skipped. If no layer has the path, [ErrNotFound]
+// or [ErrNoChildLists] is returned.
+func (o *Overlay) Children(path string) ([]string, error) {
+	var (
+		merged   []string
+		found    bool
+		firstErr error
+	)
+
+	for _, ref := range o.modules(path) {
+		children, err := ref.mod.Children(ref.path)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrNoChildLists) {
+				return nil, err
+			}
+
+			if firstErr == nil || errors.Is(err, ErrNotFound) {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		if !found {
+			merged, found = make([]string, 0, len(children)), true
+		}
+
+		for _, child := range children {
+			if !slices.Contains(merged, child) {
+				merged = append(merged, child)
+			}
+		}
+	}
+
+	if !found {
+		return nil, firstErr
+	}
+
+	return merged, nil
+}
+
+// overlayGet reads a parameter using get from the first layer having it or failing to read it.
+// The module and the full path of the layer read last are returned for logging.
+func overlayGet[T any](o *Overlay, path, typ string, get func(*Module, string) (T, error)) (T, *Module, string, error) {
+	var (
+		val T
+		m   *Module
+		p   string
+		err error
+	)
+
+	for _, ref := range o.modules(path) {
+		m, p = ref.mod, ref.path
+		if val, err = get(m, p); err != ErrNotFound {
+			break
+		}
+	}
+
+	m.countGet(typ, err)
+
+	return val, m, p, err
+}
+
+func overlayGetErr[T any](o *Overlay, path, typ string, get func(*Module, string) (T, error)) (T, error) {
+	val, _, _, err := overlayGet(o, path, typ, get)
+	return val, err
+}
+
+func overlayGetIfExists[T any](o *Overlay, path, typ string, get func(*Module, string) (T, error)) (T, bool) {
+	val, m, p, err := overlayGet(o, path, typ, get)
+	if err != nil {
+		if err != ErrNotFound {
+			m.logGetError(p, typ, err)
+		}
+
+		var zero T
+
+		return zero, false
+	}
+
+	return val, true
+}
+
+// GetStringErr calls [Module.GetStringErr] of the first layer having the parameter.
+func (o *Overlay) GetStringErr(path string) (string, error) {
+	return overlayGetErr(o, path, "string", (*Module).getString)
+}
+
+// GetStringIfExists calls [Module.GetStringIfExists] of the first layer having the parameter.
+func (o *Overlay) GetStringIfExists(path string) (string, bool) {
+	return overlayGetIfExists(o, path, "string", (*Module).getString)
+}
+
+// GetString calls [Module.GetString] of the first layer having the parameter.
+func (o *Overlay) GetString(path string, dfl string) string {
+	if val, ok := o.GetStringIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetIntErr calls [Module.GetIntErr] of the first layer having the parameter.
+func (o *Overlay) GetIntErr(path string) (int, error) {
+	return overlayGetErr(o, path, "int", (*Module).getInt)
+}
+
+// GetIntIfExists calls [Module.GetIntIfExists] of the first layer having the parameter.
+func (o *Overlay) GetIntIfExists(path string) (int, bool) {
+	return overlayGetIfExists(o, path, "int", (*Module).getInt)
+}
+
+// GetInt calls [Module.GetInt] of the first layer having the parameter.
+func (o *Overlay) GetInt(path string, dfl int) int {
+	if val, ok := o.GetIntIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetBoolErr calls [Module.GetBoolErr] of the first layer having the parameter.
+func (o *Overlay) GetBoolErr(path string) (bool, error) {
+	return overlayGetErr(o, path, "bool", (*Module).getBool)
+}
+
+// GetBoolIfExists calls [Module.GetBoolIfExists] of the first layer having the parameter.
+func (o *Overlay) GetBoolIfExists(path string) (bool, bool) {
+	return overlayGetIfExists(o, path, "bool", (*Module).getBool)
+}
+
+// GetBool calls [Module.GetBool] of the first layer having the parameter.
+func (o *Overlay) GetBool(path string, dfl bool) bool {
+	if val, ok := o.GetBoolIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetDurationErr calls [Module.GetDurationErr] of the first layer having the parameter.
+func (o *Overlay) GetDurationErr(path string) (time.Duration, error) {
+	return overlayGetErr(o, path, "duration", (*Module).getDuration)
+}
+
+// GetDurationIfExists calls [Module.GetDurationIfExists] of the first layer having the parameter.
+func (o *Overlay) GetDurationIfExists(path string) (time.Duration, bool) {
+	return overlayGetIfExists(o, path, "duration", (*Module).getDuration)
+}
+
+// GetDurationIsExists is a deprecated alias for [Overlay.GetDurationIfExists].
+//
+// Deprecated: Use [Overlay.GetDurationIfExists] instead.
+func (o *Overlay) GetDurationIsExists(path string) (time.Duration, bool) {
+	return o.GetDurationIfExists(path)
+}
+
+// GetDuration calls [Module.GetDuration] of the first layer having the parameter.
+func (o *Overlay) GetDuration(path string, dfl time.Duration) time.Duration {
+	if val, ok := o.GetDurationIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetFloatErr calls [Module.GetFloatErr] of the first layer having the parameter.
+func (o *Overlay) GetFloatErr(path string) (float64, error) {
+	return overlayGetErr(o, path, "float", (*Module).getFloat)
+}
+
+// GetFloatIfExists calls [Module.GetFloatIfExists] of the first layer having the parameter.
+func (o *Overlay) GetFloatIfExists(path string) (float64, bool) {
+	return overlayGetIfExists(o, path, "float", (*Module).getFloat)
+}
+
+// GetFloat calls [Module.GetFloat] of the first layer having the parameter.
+func (o *Overlay) GetFloat(path string, dfl float64) float64 {
+	if val, ok := o.GetFloatIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetInt64Err calls [Module.GetInt64Err] of the first layer having the parameter.
+func (o *Overlay) GetInt64Err(path string) (int64, error) {
+	return overlayGetErr(o, path, "int64", (*Module).getInt64)
+}
+
+// GetInt64IfExists calls [Module.GetInt64IfExists] of the first layer having the parameter.
+func (o *Overlay) GetInt64IfExists(path string) (int64, bool) {
+	return overlayGetIfExists(o, path, "int64", (*Module).getInt64)
+}
+
+// GetInt64 calls [Module.GetInt64] of the first layer having the parameter.
+func (o *Overlay) GetInt64(path string, dfl int64) int64 {
+	if val, ok := o.GetInt64IfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetUint64Err calls [Module.GetUint64Err] of the first layer having the parameter.
+func (o *Overlay) GetUint64Err(path string) (uint64, error) {
+	return overlayGetErr(o, path, "uint64", (*Module).getUint64)
+}
+
+// GetUint64IfExists calls [Module.GetUint64IfExists] of the first layer having the parameter.
+func (o *Overlay) GetUint64IfExists(path string) (uint64, bool) {
+	return overlayGetIfExists(o, path, "uint64", (*Module).getUint64)
+}
+
+// GetUint64 calls [Module.GetUint64] of the first layer having the parameter.
+func (o *Overlay) GetUint64(path string, dfl uint64) uint64 {
+	if val, ok := o.GetUint64IfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetByteSizeErr calls [Module.GetByteSizeErr] of the first layer having the parameter.
+func (o *Overlay) GetByteSizeErr(path string) (uint64, error) {
+	return overlayGetErr(o, path, "bytesize", (*Module).getByteSize)
+}
+
+// GetByteSizeIfExists calls [Module.GetByteSizeIfExists] of the first layer having the parameter.
+func (o *Overlay) GetByteSizeIfExists(path string) (uint64, bool) {
+	return overlayGetIfExists(o, path, "bytesize", (*Module).getByteSize)
+}
+
+// GetByteSize calls [Module.GetByteSize] of the first layer having the parameter.
+func (o *Overlay) GetByteSize(path string, dfl uint64) uint64 {
+	if val, ok := o.GetByteSizeIfExists(path); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetStringsErr calls [Module.GetStringsErr] of the first layer having the parameter.
+func (o *Overlay) GetStringsErr(path string, dfl []string) ([]string, error) {
+	return overlayGetErr(o, path, "strings", func(m *Module, p string) ([]string, error) {
+		return m.getStrings(p, dfl)
+	})
+}
+
+// GetStrings calls [Module.GetStrings] of the first layer having the parameter.
+func (o *Overlay) GetStrings(path string, dfl []string) []string {
+	if val, ok := overlayGetIfExists(o, path, "strings", func(m *Module, p string) ([]string, error) {
+		return m.getStrings(p, dfl)
+	}); ok {
+		return val
+	}
+
+	return dfl
+}
+
+// GetStruct calls [Module.GetStruct] of the first layer having the parameter.
+func (o *Overlay) GetStruct(path string, valuePtr any) (bool, error) {
+	_, _, _, err := overlayGet(o, path, "struct", func(m *Module, p string) (struct{}, error) {
+		ok, err := m.getStruct(p, valuePtr)
+		if err == nil && !ok {
+			err = ErrNotFound
+		}
+
+		return struct{}{}, err
+	})
+
+	switch err {
+	case nil:
+		return true, nil
+	case ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Bind calls [Subtree.Bind] using the overlay, every field is read from the first layer having its parameter.
+func (o *Overlay) Bind(ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if !isStructPtr(rv) {
+		return fmt.Errorf("overlay:%s: Bind accepts a non-nil pointer to a struct", o.prefix)
+	}
+
+	var errs []error
+
+	bind(o, rv.Elem(), "", &errs)
+
+	return errors.Join(errs...)
+}
+
+// All returns an iterator over all parameters of the layers. A parameter shadowed by a previous layer
+// isn't yielded. Parameters of the first layer go first in the order they are stored in its file,
+// parameters of the following layers are appended. Paths yielded are relative to the overlay prefix
+// as paths yielded by [Subtree.All]. See [Module.All] for other details.
+func (o *Overlay) All() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		var (
+			records []record
+			seen    = make(map[string]struct{})
+		)
+
+		for _, ref := range o.modules("") {
+			layerRecords, err := ref.mod.records(ref.path)
+			if err != nil {
+				ref.mod.logError("onlineconf: iteration failed", err)
+				return
+			}
+
+			for _, rec := range layerRecords {
+				rec.path = strings.TrimPrefix(rec.path, ref.path)
+				if _, ok := seen[rec.path]; ok {
+					continue
+				}
+
+				seen[rec.path] = struct{}{}
+				records = append(records, rec)
+			}
+		}
+
+		for _, rec := range records {
+			if !yield(rec.path, rec.value) {
+				return
+			}
+		}
+	}
+}
+
+// overlayWalkLayer is a layer of an overlay walk pinned to the version of its module file.
+type overlayWalkLayer struct {
+	mod    *Module
+	gen    *generation
+	prefix string
+}
+
+func (l overlayWalkLayer) path(path string) string {
+	return cleanPath(l.prefix + path)
+}
+
+// Walk walks the subtree rooted at the path using child lists merged across the layers as [Overlay.Children]
+// does, calling fn for every existing value with the value of the first layer having it. Paths passed to fn
+// are relative to the overlay prefix. Layers without child lists are skipped, [ErrNoChildLists] is returned
+// if no layer has them. See [Module.Walk] for other details.
+func (o *Overlay) Walk(path string, fn WalkFunc) error {
+	var (
+		layers   []overlayWalkLayer
+		firstErr error
+	)
+
+	for _, ref := range (&Overlay{layers: o.layers}).modules("") {
+		gen, shard := ref.mod.pin()
+		if gen == nil {
+			return ErrClosed
+		}
+
+		defer ref.mod.unpin(gen, shard)
+
+		if err := ref.mod.hasChildLists(gen); err != nil {
+			if !errors.Is(err, ErrNoChildLists) {
+				return err
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		layers = append(layers, overlayWalkLayer{mod: ref.mod, gen: gen, prefix: ref.path})
+	}
+
+	if len(layers) == 0 {
+		return firstErr
+	}
+
+	err := walkLayers(layers, cleanPath(o.prefix+path), func(path string, value Value) error {
+		return fn(strings.TrimPrefix(path, o.prefix), value)
+	})
+	if err == SkipSubtree {
+		return nil
+	}
+
+	return err
+}
+
+func walkLayers(layers []overlayWalkLayer, path string, fn WalkFunc) error {
+	for _, l := range layers {
+		full := l.path(path)
+		if full == "/" { // the root "value" is a child list
+			continue
+		}
+
+		data, err := l.mod.genRaw(l.gen, full)
+		if err != nil {
+			return err
+		}
+
+		if len(data) != 0 {
+			if err := fn(path, rawToValue(data)); err != nil {
+				return err
+			}
+
+			break
+		}
+	}
+
+	var merged []string
+
+	for _, l := range layers {
+		children, err := l.mod.genStringsRaw(l.gen, childListPath(l.path(path)))
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if !slices.Contains(merged, child) {
+				merged = append(merged, child)
+			}
+		}
+	}
+
+	for _, child := range merged {
+		if err := walkLayers(layers, joinChildPath(path, child), fn); err != nil && err != SkipSubtree {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubscribeChan calls [Module.SubscribeChan] of all the layers.
+// If a layer fails, the channel is unsubscribed from the layers subscribed already.
+func (o *Overlay) SubscribeChan(path string, ch chan<- struct{}) error {
+	return o.subscribeChan(path, false, ch)
+}
+
+// Subscribe makes a channel with a capacity of 1 and calls [Overlay.SubscribeChan].
+func (o *Overlay) Subscribe(path string) (chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	return ch, o.subscribeChan(path, false, ch)
+}
+
+// SubscribeChanSubtree calls [Module.SubscribeChanSubtree] of all the layers.
+// If a layer fails, the channel is unsubscribed from the layers subscribed already.
+func (o *Overlay) SubscribeChanSubtree(path string, ch chan<- struct{}) error {
+	return o.subscribeChan(path, true, ch)
+}
+
+// SubscribeSubtree makes a channel with a capacity of 1 and calls [Overlay.SubscribeChanSubtree].
+func (o *Overlay) SubscribeSubtree(path string) (chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	return ch, o.subscribeChan(path, true, ch)
+}
+
+func (o *Overlay) subscribeChan(path string, isRecursive bool, ch chan<- struct{}) error {
+	refs := o.modules(path)
+
+	for i, ref := range refs {
+		if err := ref.mod.subscribeChan(ref.path, isRecursive, ch); err != nil {
+			for _, ref := range refs[:i] {
+				ref.mod.unsubscribeChan(ref.path, isRecursive, ch)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnsubscribeChan removes the subscription made by [Overlay.SubscribeChan] or [Overlay.Subscribe]
+// from all the layers and closes the channel, see [Module.UnsubscribeChan].
+func (o *Overlay) UnsubscribeChan(path string, ch chan<- struct{}) {
+	o.unsubscribeChan(path, false, ch)
+}
+
+// UnsubscribeChanSubtree removes the subscription made by [Overlay.SubscribeChanSubtree] or [Overlay.SubscribeSubtree]
+// from all the layers and closes the channel, see [Module.UnsubscribeChanSubtree].
+func (o *Overlay) UnsubscribeChanSubtree(path string, ch chan<- struct{}) {
+	o.unsubscribeChan(path, true, ch)
+}
+
+func (o *Overlay) unsubscribeChan(path string, isRecursive bool, ch chan<- struct{}) {
+	for _, ref := range o.modules(path) {
+		ref.mod.unsubscribeChan(ref.path, isRecursive, ch)
+	}
+
+	safeClose(ch)
+}
+
+// Unsubscribe calls [Module.Unsubscribe] of all the layers, so channels subscribed directly
+// to the layers are unsubscribed too.
+func (o *Overlay) Unsubscribe(path string) {
+	for _, ref := range o.modules(path) {
+		ref.mod.Unsubscribe(ref.path)
+	}
+}
+
+// UnsubscribeSubtree calls [Module.UnsubscribeSubtree] of all the layers, so channels subscribed directly
+// to the layers are unsubscribed too.
+func (o *Overlay) UnsubscribeSubtree(path string) {
+	for _, ref := range o.modules(path) {
+		ref.mod.UnsubscribeSubtree(ref.path)
+	}
+}
+
+// SubscribeEventsChan creates a subscription for the specified path delivering change events of the overlay.
+//
+// The channel is notified by all the layers, and the value is read again as [Overlay] getters do, so an event
+// is sent if the value of the overlay is changed. Events are sent asynchronously after reloads of the layers.
+// Paths of events are relative to the overlay prefix. The channel is closed when it's unsubscribed or a layer
+// module is closed. See [Module.SubscribeEventsChan] for other details.
+func (o *Overlay) SubscribeEventsChan(path string, ch chan<- Event) error {
+	return o.subscribeEventsChan(path, false, ch)
+}
+
+// SubscribeEvents makes a buffered channel and calls [Overlay.SubscribeEventsChan].
+func (o *Overlay) SubscribeEvents(path string) (chan Event, error) {
+	ch := make(chan Event, eventChanCap)
+	return ch, o.subscribeEventsChan(path, false, ch)
+}
+
+// SubscribeEventsChanSubtree creates a subscription for the specified path itself and all descending paths
+// delivering change events of the overlay. Values are read as [Overlay.Walk] does.
+// See [Overlay.SubscribeEventsChan] and [Module.SubscribeEventsChanSubtree] for other details.
+func (o *Overlay) SubscribeEventsChanSubtree(path string, ch chan<- Event) error {
+	return o.subscribeEventsChan(path, true, ch)
+}
+
+// SubscribeEventsSubtree makes a buffered channel and calls [Overlay.SubscribeEventsChanSubtree].
+func (o *Overlay) SubscribeEventsSubtree(path string) (chan Event, error) {
+	ch := make(chan Event, eventChanCap)
+	return ch, o.subscribeEventsChan(path, true, ch)
+}
+
+func (o *Overlay) subscribeEventsChan(path string, isRecursive bool, ch chan<- Event) error {
+	full := cleanPath(o.prefix + path)
+	key := overlayEventKey{subscriptionKey: subscriptionKey{path: full, isRecursive: isRecursive}, ch: ch}
+
+	changed := make(chan struct{}, 1)
+	if err := o.subscribeChan(path, isRecursive, changed); err != nil { // before reading, so no change is missed
+		return err
+	}
+
+	values, _, err := o.eventValues(path, isRecursive)
+	if err != nil {
+		o.unsubscribeChan(path, isRecursive, changed)
+		return err
+	}
+
+	o.events.mutex.Lock()
+	defer o.events.mutex.Unlock()
+
+	if _, ok := o.events.channels[key]; ok {
+		o.unsubscribeChan(path, isRecursive, changed)
+		return nil
+	}
+
+	if o.events.channels == nil {
+		o.events.channels = map[overlayEventKey]chan struct{}{}
+	}
+
+	o.events.channels[key] = changed
+
+	go o.sendEvents(path, key, values, changed)
+
+	return nil
+}
+
+// sendEvents sends events to the channel on notifications of the layers until the channel
+// is unsubscribed from the layers. The channel is closed after that.
+func (o *Overlay) sendEvents(path string, key overlayEventKey, values map[string]subscrValue, changed chan struct{}) {
+	defer safeClose(key.ch)
+
+	defer func() {
+		o.events.mutex.Lock()
+		defer o.events.mutex.Unlock()
+
+		if o.events.channels[key] == changed {
+			delete(o.events.channels, key)
+		}
+	}()
+
+	eventKey := subscriptionKey{path: strings.TrimPrefix(key.path, o.prefix), isRecursive: key.isRecursive}
+
+	for range changed {
+		newValues, current, err := o.eventValues(path, key.isRecursive)
+		if err != nil {
+			logger().LogAttrs(context.Background(), slog.LevelError, "onlineconf: overlay event failed",
+				slog.String("path", key.path),
+				slog.String("kind", errorKind(err)),
+				slog.Any("error", err),
+			)
+
+			continue
+		}
+
+		ev, ok := makeEvent(eventKey, values, newValues, func(path string) Value { return current[path] })
+		if !ok {
+			continue
+		}
+
+		values = newValues
+
+		if isNotified, _ := notify(key.ch, ev); !isNotified { // closed without unsubscribing
+			o.unsubscribeChan(path, key.isRecursive, changed)
+		}
+	}
+}
+
+// eventValues reads the value of the path and, for subtree subscriptions, of its descendants as getters
+// and [Overlay.Walk] do. Non-existent values aren't included. Paths are relative to the overlay prefix.
+func (o *Overlay) eventValues(path string, isRecursive bool) (map[string]subscrValue, map[string]Value, error) {
+	values, current := map[string]subscrValue{}, map[string]Value{}
+
+	add := func(path string, value Value) error {
+		values[path] = newSubscrValue(append([]byte{byte(value.Format)}, value.Data...))
+		current[path] = value
+
+		return nil
+	}
+
+	if isRecursive {
+		return values, current, o.Walk(path, add)
+	}
+
+	for _, ref := range o.modules(path) {
+		format, data, _, err := ref.mod.lookup(cleanPath(ref.path))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if format != 0 {
+			return values, current, add(strings.TrimPrefix(cleanPath(o.prefix+path), o.prefix), Value{Format: Format(format), Data: data})
+		}
+	}
+
+	return values, current, nil
+}
+
+// UnsubscribeEventsChan removes the subscription made by [Overlay.SubscribeEventsChan] or [Overlay.SubscribeEvents]
+// for the path and the channel specified. The channel is closed.
+func (o *Overlay) UnsubscribeEventsChan(path string, ch chan<- Event) {
+	o.unsubscribeEventsChan(path, false, ch)
+}
+
+// UnsubscribeEventsChanSubtree removes the subscription made by [Overlay.SubscribeEventsChanSubtree] or
+// [Overlay.SubscribeEventsSubtree] for the path and the channel specified. The channel is closed.
+func (o *Overlay) UnsubscribeEventsChanSubtree(path string, ch chan<- Event) {
+	o.unsubscribeEventsChan(path, true, ch)
+}
+
+func (o *Overlay) unsubscribeEventsChan(path string, isRecursive bool, ch chan<- Event) {
+	key := overlayEventKey{subscriptionKey: subscriptionKey{path: cleanPath(o.prefix + path), isRecursive: isRecursive}, ch: ch}
+
+	o.events.mutex.Lock()
+	changed, ok := o.events.channels[key]
+	delete(o.events.channels, key)
+	o.events.mutex.Unlock()
+
+	if ok {
+		o.unsubscribeChan(path, isRecursive, changed) // stops sendEvents
+	}
+
+	safeClose(ch)
+}
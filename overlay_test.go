@@ -0,0 +1,256 @@
+package onlineconf
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay(t *testing.T) {
+	local, err := NewModuleFromJSON("local", []byte(`{"db": {"host": "localhost"}}`))
+	require.NoError(t, err)
+
+	defaults, err := NewModuleFromMap("defaults", map[string]any{
+		"db":       map[string]any{"host": "db.example.com", "port": 5432, "timeout": "1s"},
+		"/default": "value",
+	})
+	require.NoError(t, err)
+
+	fname := filepath.Join(t.TempDir(), "TREE.cdb")
+	writeCDB(t, fname, map[string]string{"/db/port": "6432", "/db/user": "app"})
+
+	tree, err := OpenModule(fname)
+	require.NoError(t, err)
+
+	defer tree.Close()
+
+	overlay := NewOverlay(local, tree, defaults)
+
+	assert.Equal(t, "localhost", overlay.GetString("/db/host", ""), "the first layer must shadow the following ones")
+	assert.Equal(t, 6432, overlay.GetInt("/db/port", 0))
+	assert.Equal(t, "value", overlay.GetString("/default", ""))
+	assert.Equal(t, "dfl", overlay.GetString("/missing", "dfl"))
+	assert.Equal(t, 6432, Get(overlay, "/db/port", 0), "generic getters must read the overlay")
+
+	_, err = overlay.GetStringErr("/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	children, err := overlay.Children("/db")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"host", "port", "timeout", "user"}, children)
+
+	_, err = overlay.Children("/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	db := overlay.Subtree("/db")
+	assert.Equal(t, "localhost", db.GetString("/host", ""))
+	assert.Equal(t, "app", db.GetString("/user", ""))
+
+	nested := NewOverlay(NewOverlay(local), tree.Subtree("/db"))
+	assert.Equal(t, "app", nested.GetString("/user", ""))
+
+	ch, err := db.Subscribe("/port")
+	require.NoError(t, err)
+
+	writeCDB(t, fname, map[string]string{"/db/port": "7432", "/db/user": "app"})
+	require.NoError(t, tree.Reload())
+	waitChan(t, "/db/port", ch)
+	assert.Equal(t, 7432, db.GetInt("/port", 0))
+
+	db.UnsubscribeChan("/port", ch)
+
+	_, ok := <-ch
+	assert.False(t, ok, "the channel must be closed")
+
+	tree.mutex.Lock()
+	assert.Empty(t, tree.subscriptions, "the channel must be unsubscribed from all the layers")
+	tree.mutex.Unlock()
+}
+
+func TestOverlayEmpty(t *testing.T) {
+	overlay := NewOverlay()
+
+	_, err := overlay.GetStringErr("/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 42, overlay.GetInt("/missing", 42))
+	assert.Equal(t, "dfl", Get(overlay, "/missing", "dfl"))
+
+	ok, err := overlay.GetStruct("/missing", &struct{}{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	for range overlay.All() {
+		t.Error("an empty overlay must have no parameters")
+	}
+}
+
+func TestOverlayBindAllWalk(t *testing.T) {
+	local, err := NewModuleFromMap("local", map[string]any{
+		"db": map[string]any{"host": "localhost", "replica": map[string]any{"hosts": "r1"}},
+	})
+	require.NoError(t, err)
+
+	defaults, err := NewModuleFromMap("defaults", map[string]any{
+		"db":  map[string]any{"host": "db.example.com", "port": 5432, "timeout": "1s"},
+		"log": "info",
+	})
+	require.NoError(t, err)
+
+	overlay := NewOverlay(local, defaults)
+
+	var cfg struct {
+		Host    string        `onlineconf:"/host,required"`
+		Port    int           `onlineconf:"/port"`
+		Timeout time.Duration `onlineconf:"/timeout"`
+		User    string        `onlineconf:"/user" default:"app"`
+		Replica struct {
+			Hosts []string `onlineconf:"/hosts"`
+		} `onlineconf:"/replica"`
+	}
+
+	require.NoError(t, overlay.Subtree("/db").Bind(&cfg))
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 5432, cfg.Port)
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, "app", cfg.User)
+	assert.Equal(t, []string{"r1"}, cfg.Replica.Hosts)
+
+	var missing struct {
+		Name string `onlineconf:"/name,required"`
+	}
+
+	err = overlay.Bind(&missing)
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Contains(t, err.Error(), "defaults:/name")
+
+	got := map[string]string{}
+	for path, val := range overlay.Subtree("/db").All() {
+		got[path] = val.String()
+	}
+
+	assert.Equal(t, map[string]string{
+		"/host": "localhost", "/replica/hosts": "r1", "/port": "5432", "/timeout": "1s",
+	}, got)
+
+	var paths []string
+
+	err = overlay.Walk("/db", func(path string, val Value) error {
+		paths = append(paths, path+"="+val.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"/db/host=localhost", "/db/replica/hosts=r1", "/db/port=5432", "/db/timeout=1s",
+	}, paths)
+
+	paths = nil
+	err = NewOverlay(local.Subtree("/db"), defaults.Subtree("/db")).Walk("/", func(path string, _ Value) error {
+		paths = append(paths, path)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/host", "/replica/hosts", "/port", "/timeout"}, paths)
+}
+
+func TestOverlayUnsubscribe(t *testing.T) {
+	first, err := NewModuleFromMap("first", map[string]string{"/a": "1"})
+	require.NoError(t, err)
+
+	second, err := NewModuleFromMap("second", map[string]string{"/a": "2"})
+	require.NoError(t, err)
+
+	overlay := NewOverlay(first, second)
+
+	ch, err := overlay.Subscribe("/a")
+	require.NoError(t, err)
+
+	subtreeCh, err := overlay.SubscribeSubtree("/")
+	require.NoError(t, err)
+
+	overlay.Unsubscribe("/a")
+	overlay.UnsubscribeSubtree("/")
+
+	_, ok := <-ch
+	assert.False(t, ok, "the channel must be closed")
+
+	_, ok = <-subtreeCh
+	assert.False(t, ok, "the channel must be closed")
+
+	for _, mod := range []*Module{first, second} {
+		mod.mutex.Lock()
+		assert.Empty(t, mod.subscriptions, "the channels must be unsubscribed from all the layers")
+		mod.mutex.Unlock()
+	}
+}
+
+func TestOverlayEvents(t *testing.T) {
+	local, localName := newTestModule(t, map[string]string{"/db/host": "localhost"})
+	tree, treeName := newTestModule(t, map[string]string{"/db/host": "db.example.com", "/db/port": "5432"})
+
+	overlay := NewOverlay(local, tree)
+
+	hostCh, err := overlay.Subtree("/db").SubscribeEvents("/host")
+	require.NoError(t, err)
+
+	dbCh, err := overlay.SubscribeEventsSubtree("/db")
+	require.NoError(t, err)
+
+	writeCDB(t, treeName, map[string]string{"/db/host": "db2.example.com", "/db/port": "6432"})
+	require.NoError(t, tree.Reload())
+
+	assert.Equal(t, Event{
+		Change: Change{Path: "/db"},
+		Children: []Change{{
+			Path: "/db/port",
+			Kind: Updated,
+			Old:  Value{Format: FormatText, Data: []byte("5432")},
+			New:  Value{Format: FormatText, Data: []byte("6432")},
+		}},
+	}, waitEvent(t, "/db", dbCh), "changes of shadowed values must not be reported")
+
+	writeCDB(t, localName, map[string]string{"/other": "value"})
+	require.NoError(t, local.Reload())
+
+	assert.Equal(t, Event{Change: Change{
+		Path: "/host",
+		Kind: Updated,
+		Old:  Value{Format: FormatText, Data: []byte("localhost")},
+		New:  Value{Format: FormatText, Data: []byte("db2.example.com")},
+	}}, waitEvent(t, "/host", hostCh), "the value of the next layer must be reported")
+
+	ev := waitEvent(t, "/db", dbCh)
+	assert.Equal(t, []Change{{
+		Path: "/db/host",
+		Kind: Updated,
+		Old:  Value{Format: FormatText, Data: []byte("localhost")},
+		New:  Value{Format: FormatText, Data: []byte("db2.example.com")},
+	}}, ev.Children)
+
+	overlay.Subtree("/db").UnsubscribeEventsChan("/host", hostCh)
+	overlay.UnsubscribeEventsChanSubtree("/db", dbCh)
+
+	_, ok := <-hostCh
+	assert.False(t, ok, "the channel must be closed")
+
+	for _, mod := range []*Module{local, tree} {
+		mod.mutex.Lock()
+		assert.Empty(t, mod.subscriptions, "the channels must be unsubscribed from all the layers")
+		mod.mutex.Unlock()
+	}
+
+	ch, err := overlay.SubscribeEvents("/db/port")
+	require.NoError(t, err)
+	require.NoError(t, tree.Close())
+
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond, "the channel must be closed with a layer module")
+}
@@ -0,0 +1,109 @@
+package onlineconf
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is the interval used by [NewPollingWatcher] if a non-positive interval is passed.
+const DefaultPollInterval = time.Second
+
+// NewPollingWatcher returns a [Watcher] checking module files every interval using [os.Stat].
+// A file is considered changed if its inode, size, or modification time differs from the previous check.
+// Symlinks are followed, so replacements of symlinks are detected as well.
+//
+// Use it on file systems where inotify events never arrive, e.g. NFS, overlayfs, or bind mounts into containers.
+// All the files tracked by the watcher are checked by a single goroutine, which is stopped
+// when no files are tracked.
+func NewPollingWatcher(interval time.Duration) Watcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	return &pollingWatcher{
+		interval: interval,
+		files:    make(map[*polledFile]struct{}),
+	}
+}
+
+type pollingWatcher struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	files    map[*polledFile]struct{}
+	stop     chan struct{} // closed to stop the polling goroutine, nil if it isn't running
+}
+
+type polledFile struct {
+	filename string
+	changed  func()
+	fileInfo os.FileInfo // the last stat result, nil if the file didn't exist at that moment
+}
+
+func (w *pollingWatcher) Watch(filename string, changed func()) (func(), error) {
+	pf := &polledFile{
+		filename: filename,
+		changed:  changed,
+	}
+
+	pf.fileInfo, _ = os.Stat(filename) // a missing file is loaded when it's created
+
+	w.mutex.Lock()
+	w.files[pf] = struct{}{}
+
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+		go w.poll(w.stop)
+	}
+	w.mutex.Unlock()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			w.mutex.Lock()
+			defer w.mutex.Unlock()
+
+			delete(w.files, pf)
+
+			if len(w.files) == 0 {
+				close(w.stop)
+				w.stop = nil
+			}
+		})
+	}, nil
+}
+
+func (w *pollingWatcher) poll(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		w.mutex.Lock()
+		files := make([]*polledFile, 0, len(w.files))
+		for pf := range w.files {
+			files = append(files, pf)
+		}
+		w.mutex.Unlock()
+
+		for _, pf := range files {
+			fileInfo, err := os.Stat(pf.filename)
+			if err != nil { // may be missing while it's being replaced
+				continue
+			}
+
+			if isSameFile(pf.fileInfo, fileInfo) {
+				continue
+			}
+
+			pf.fileInfo = fileInfo
+			callChanged(pf.changed)
+		}
+	}
+}
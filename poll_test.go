@@ -0,0 +1,45 @@
+package onlineconf
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollingWatcher(t *testing.T) {
+	dir := t.TempDir()
+	cdbName := filepath.Join(dir, "poll.cdb")
+	writeCDB(t, cdbName, map[string]string{"/key": "old"})
+
+	pw := NewPollingWatcher(10 * time.Millisecond)
+
+	SetDefaultWatcher(pw)
+	defer SetDefaultWatcher(nil)
+
+	mod, err := OpenModule(cdbName)
+	require.NoError(t, err)
+
+	w, err := initWatcherOnce()
+	require.NoError(t, err)
+	assert.Zero(t, w.dirs[dir], "the directory must not be watched by fsnotify")
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	writeCDB(t, cdbName, map[string]string{"/key": "new"})
+	waitChan(t, "/key", ch)
+	assert.Equal(t, "new", mod.GetString("/key", ""))
+
+	require.NoError(t, mod.SetWatcher(NewFSNotifyWatcher()))
+	assert.Equal(t, 2, w.dirs[dir], "the directory must be watched by fsnotify for the file and its checksum sidecar")
+	assert.Nil(t, pw.(*pollingWatcher).stop, "polling must be stopped")
+
+	require.NoError(t, mod.SetWatcher(pw))
+	assert.Zero(t, w.dirs[dir])
+
+	require.NoError(t, mod.Close())
+	assert.Nil(t, pw.(*pollingWatcher).stop, "polling must be stopped by Close")
+}
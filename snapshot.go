@@ -0,0 +1,239 @@
+package onlineconf
+
+import (
+	"iter"
+	"reflect"
+	"time"
+)
+
+// Snapshot is an immutable read-only view of a [Module] pinned to the version of the module file
+// loaded when the snapshot was made.
+//
+// Values read from a snapshot are always consistent with each other, even if the module file is
+// reloaded between reads. The file version is kept mapped until the snapshot is released,
+// so snapshots should be short-lived and must be released using [Snapshot.Release].
+//
+// Subtrees of a snapshot returned by [Snapshot.Subtree] are snapshots too.
+type Snapshot struct {
+	sub *Subtree // a subtree of a private module which is never reloaded
+}
+
+// Snapshot makes a [Snapshot] of the currently loaded version of the module file.
+func (m *Module) Snapshot() (*Snapshot, error) {
+	for {
+		gen, shard := m.pin()
+		if gen == nil {
+			return nil, ErrClosed
+		}
+
+		owned := gen.tryAcquire() // fails if the generation is retired by a reload concurrently
+		m.unpin(gen, shard)
+
+		if owned {
+			return m.snapshot(gen), nil
+		}
+	}
+}
+
+// snapshot makes a snapshot of the generation taking over the caller's ownership of it.
+func (m *Module) snapshot(gen *generation) *Snapshot {
+	mod := &Module{
+		name:     m.name,
+		filename: m.filename, // only for errors and metrics, the module is never reloaded
+		refs:     1,
+	}
+
+	mod.gen.Store(gen)
+	mod.ownLogger.Store(m.ownLogger.Load())
+	mod.env.Store(m.env.Load())
+
+	return &Snapshot{sub: mod.Subtree("")}
+}
+
+// Release releases the version of the module file, it's unmapped after reads in progress are done
+// unless it's still current or used by other snapshots. All Get* methods of the released snapshot return
+// [ErrClosed] or default values. Calling Release on an already released snapshot does nothing.
+func (s *Snapshot) Release() {
+	if gen := s.sub.mod.gen.Swap(nil); gen != nil {
+		s.sub.mod.releaseGen(gen)
+	}
+}
+
+func (s *Snapshot) read(path string, rv reflect.Value) (*Module, string, error) {
+	return s.sub.read(path, rv)
+}
+
+func (s *Snapshot) modules(path string) []moduleRef {
+	return s.sub.modules(path)
+}
+
+func (s *Snapshot) subtree(prefix string) Source {
+	return s.Subtree(prefix)
+}
+
+// Path returns a full path in the module using the prefix of the snapshot subtree.
+func (s *Snapshot) Path(path string) string {
+	return s.sub.Path(path)
+}
+
+// GetStringErr calls [Module.GetStringErr] using the snapshot.
+func (s *Snapshot) GetStringErr(path string) (string, error) {
+	return s.sub.GetStringErr(path)
+}
+
+// GetStringIfExists calls [Module.GetStringIfExists] using the snapshot.
+func (s *Snapshot) GetStringIfExists(path string) (string, bool) {
+	return s.sub.GetStringIfExists(path)
+}
+
+// GetString calls [Module.GetString] using the snapshot.
+func (s *Snapshot) GetString(path string, dfl string) string {
+	return s.sub.GetString(path, dfl)
+}
+
+// GetIntErr calls [Module.GetIntErr] using the snapshot.
+func (s *Snapshot) GetIntErr(path string) (int, error) {
+	return s.sub.GetIntErr(path)
+}
+
+// GetIntIfExists calls [Module.GetIntIfExists] using the snapshot.
+func (s *Snapshot) GetIntIfExists(path string) (int, bool) {
+	return s.sub.GetIntIfExists(path)
+}
+
+// GetInt calls [Module.GetInt] using the snapshot.
+func (s *Snapshot) GetInt(path string, dfl int) int {
+	return s.sub.GetInt(path, dfl)
+}
+
+// GetBoolErr calls [Module.GetBoolErr] using the snapshot.
+func (s *Snapshot) GetBoolErr(path string) (bool, error) {
+	return s.sub.GetBoolErr(path)
+}
+
+// GetBoolIfExists calls [Module.GetBoolIfExists] using the snapshot.
+func (s *Snapshot) GetBoolIfExists(path string) (bool, bool) {
+	return s.sub.GetBoolIfExists(path)
+}
+
+// GetBool calls [Module.GetBool] using the snapshot.
+func (s *Snapshot) GetBool(path string, dfl bool) bool {
+	return s.sub.GetBool(path, dfl)
+}
+
+// GetDurationErr calls [Module.GetDurationErr] using the snapshot.
+func (s *Snapshot) GetDurationErr(path string) (time.Duration, error) {
+	return s.sub.GetDurationErr(path)
+}
+
+// GetDurationIfExists calls [Module.GetDurationIfExists] using the snapshot.
+func (s *Snapshot) GetDurationIfExists(path string) (time.Duration, bool) {
+	return s.sub.GetDurationIfExists(path)
+}
+
+// GetDuration calls [Module.GetDuration] using the snapshot.
+func (s *Snapshot) GetDuration(path string, dfl time.Duration) time.Duration {
+	return s.sub.GetDuration(path, dfl)
+}
+
+// GetFloatErr calls [Module.GetFloatErr] using the snapshot.
+func (s *Snapshot) GetFloatErr(path string) (float64, error) {
+	return s.sub.GetFloatErr(path)
+}
+
+// GetFloatIfExists calls [Module.GetFloatIfExists] using the snapshot.
+func (s *Snapshot) GetFloatIfExists(path string) (float64, bool) {
+	return s.sub.GetFloatIfExists(path)
+}
+
+// GetFloat calls [Module.GetFloat] using the snapshot.
+func (s *Snapshot) GetFloat(path string, dfl float64) float64 {
+	return s.sub.GetFloat(path, dfl)
+}
+
+// GetInt64Err calls [Module.GetInt64Err] using the snapshot.
+func (s *Snapshot) GetInt64Err(path string) (int64, error) {
+	return s.sub.GetInt64Err(path)
+}
+
+// GetInt64IfExists calls [Module.GetInt64IfExists] using the snapshot.
+func (s *Snapshot) GetInt64IfExists(path string) (int64, bool) {
+	return s.sub.GetInt64IfExists(path)
+}
+
+// GetInt64 calls [Module.GetInt64] using the snapshot.
+func (s *Snapshot) GetInt64(path string, dfl int64) int64 {
+	return s.sub.GetInt64(path, dfl)
+}
+
+// GetUint64Err calls [Module.GetUint64Err] using the snapshot.
+func (s *Snapshot) GetUint64Err(path string) (uint64, error) {
+	return s.sub.GetUint64Err(path)
+}
+
+// GetUint64IfExists calls [Module.GetUint64IfExists] using the snapshot.
+func (s *Snapshot) GetUint64IfExists(path string) (uint64, bool) {
+	return s.sub.GetUint64IfExists(path)
+}
+
+// GetUint64 calls [Module.GetUint64] using the snapshot.
+func (s *Snapshot) GetUint64(path string, dfl uint64) uint64 {
+	return s.sub.GetUint64(path, dfl)
+}
+
+// GetByteSizeErr calls [Module.GetByteSizeErr] using the snapshot.
+func (s *Snapshot) GetByteSizeErr(path string) (uint64, error) {
+	return s.sub.GetByteSizeErr(path)
+}
+
+// GetByteSizeIfExists calls [Module.GetByteSizeIfExists] using the snapshot.
+func (s *Snapshot) GetByteSizeIfExists(path string) (uint64, bool) {
+	return s.sub.GetByteSizeIfExists(path)
+}
+
+// GetByteSize calls [Module.GetByteSize] using the snapshot.
+func (s *Snapshot) GetByteSize(path string, dfl uint64) uint64 {
+	return s.sub.GetByteSize(path, dfl)
+}
+
+// GetStringsErr calls [Module.GetStringsErr] using the snapshot.
+func (s *Snapshot) GetStringsErr(path string, dfl []string) ([]string, error) {
+	return s.sub.GetStringsErr(path, dfl)
+}
+
+// GetStrings calls [Module.GetStrings] using the snapshot.
+func (s *Snapshot) GetStrings(path string, dfl []string) []string {
+	return s.sub.GetStrings(path, dfl)
+}
+
+// GetStruct calls [Module.GetStruct] using the snapshot.
+func (s *Snapshot) GetStruct(path string, valuePtr interface{}) (bool, error) {
+	return s.sub.GetStruct(path, valuePtr)
+}
+
+// Bind calls [Module.Bind] using the snapshot.
+func (s *Snapshot) Bind(ptr any) error {
+	return s.sub.Bind(ptr)
+}
+
+// All calls [Module.All] using the snapshot.
+func (s *Snapshot) All() iter.Seq2[string, Value] {
+	return s.sub.All()
+}
+
+// Walk calls [Module.Walk] using the snapshot.
+func (s *Snapshot) Walk(path string, fn WalkFunc) error {
+	return s.sub.Walk(path, fn)
+}
+
+// Children calls [Module.Children] using the snapshot.
+func (s *Snapshot) Children(path string) ([]string, error) {
+	return s.sub.Children(path)
+}
+
+// Subtree returns a snapshot of the subtree rooted at the specified prefix sharing the version
+// of the module file with s, see [Module.Subtree] for details. Releasing any of them releases both.
+// Subtrees of snapshots can't be subscribed to, since snapshots never change.
+func (s *Snapshot) Subtree(prefix string) *Snapshot {
+	return &Snapshot{sub: s.sub.Subtree(prefix)}
+}
@@ -0,0 +1,68 @@
+package onlineconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/db/host": "old.local", "/db/port": "5432"})
+
+	snap, err := mod.Snapshot()
+	require.NoError(t, err)
+
+	oldGen := snap.sub.mod.gen.Load()
+
+	writeCDB(t, cdbName, map[string]string{"/db/host": "new.local", "/db/port": "6432"})
+	require.NoError(t, mod.Reload())
+
+	assert.Equal(t, "new.local", mod.GetString("/db/host", ""))
+	assert.Equal(t, "old.local", snap.GetString("/db/host", ""), "the snapshot must be pinned")
+	assert.Equal(t, 5432, snap.Subtree("/db").GetInt("/port", 0), "subtrees of the snapshot must be pinned")
+	assert.Equal(t, 5432, Get(snap, "/db/port", 0))
+	assert.False(t, oldGen.unmapped.Load(), "the old version must stay mapped")
+
+	snap.Subtree("/db").Release()
+	snap.Release()
+
+	assert.True(t, oldGen.unmapped.Load(), "the old version must be unmapped after release")
+
+	_, err = snap.GetStringErr("/db/host")
+	assert.ErrorIs(t, err, ErrClosed)
+
+	snap, err = mod.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, "new.local", snap.GetString("/db/host", ""))
+
+	require.NoError(t, mod.Close())
+	assert.Equal(t, "new.local", snap.GetString("/db/host", ""), "the snapshot must outlive the module")
+	snap.Release()
+
+	mod, err = OpenModule(cdbName) // for the deferred Close
+	require.NoError(t, err)
+}
+
+func TestGenerationDrain(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/key": "old"})
+
+	gen, shard := mod.pin() // a read in progress
+
+	writeCDB(t, cdbName, map[string]string{"/key": "new"})
+	require.NoError(t, mod.Reload())
+
+	assert.True(t, gen.retired.Load())
+	assert.False(t, gen.unmapped.Load(), "the old version must stay mapped while it's read")
+
+	data, err := mod.genRaw(gen, "/key")
+	require.NoError(t, err)
+	assert.Equal(t, "sold", string(data))
+
+	mod.unpin(gen, shard)
+	assert.True(t, gen.unmapped.Load(), "the old version must be unmapped by the last reader")
+
+	newGen := mod.gen.Load()
+	require.NoError(t, mod.Close())
+	assert.True(t, newGen.unmapped.Load(), "the file must be unmapped on Close")
+}
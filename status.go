@@ -0,0 +1,99 @@
+package onlineconf
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Status describes the state of a module for health checks.
+//
+// A module is stuck on a stale version of the file if the last reload failed,
+// i.e. LastErrorAt is after LoadedAt.
+type Status struct {
+	Generation  uint64    // number of versions of the file loaded, 1 after OpenModule
+	LoadedAt    time.Time // time the current version was loaded
+	Size        int64     // size of the current version of the file, 0 for modules created by NewModule*
+	ModTime     time.Time // modification time of the current version of the file, zero for modules created by NewModule*
+	Checksum    string    // hex-encoded digest of the current version verified against its sidecar file, empty if not verified
+	LastError   error     // the last error reported to [Module.OnError] callbacks, nil if there were no errors
+	LastErrorAt time.Time // time of the last error
+	Closed      bool      // whether the module is closed
+}
+
+// Status returns the current state of the module.
+func (m *Module) Status() Status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := Status{
+		Generation:  m.generation,
+		LoadedAt:    m.loadedAt,
+		LastError:   m.lastError,
+		LastErrorAt: m.lastErrorAt,
+		Closed:      m.closed,
+	}
+
+	if gen := m.gen.Load(); gen != nil && gen.fileInfo != nil {
+		status.Size = gen.fileInfo.Size()
+		status.ModTime = gen.fileInfo.ModTime()
+		status.Checksum = hex.EncodeToString(gen.checksum)
+	}
+
+	return status
+}
+
+// OnError registers a callback called with errors occurred while reloading the module file
+// in the background or by [Module.Reload]: failures to load a new version (which leave the current one active),
+// rejections by validators, and errors of reading subscribed values. Errors are logged anyway.
+//
+// Callbacks are called sequentially from the goroutine reloading the module,
+// before the callbacks registered by the package-level [OnError].
+func (m *Module) OnError(fn func(err error)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.errorCallbacks = append(m.errorCallbacks, fn)
+}
+
+var errorCallbacks struct {
+	sync.Mutex
+	fns []func(*Module, error)
+}
+
+// OnError registers a callback called with errors of all the modules (see [Module.OnError])
+// and errors of the file watcher, which aren't related to any module and are passed with a nil module.
+func OnError(fn func(m *Module, err error)) {
+	errorCallbacks.Lock()
+	defer errorCallbacks.Unlock()
+
+	errorCallbacks.fns = append(errorCallbacks.fns, fn)
+}
+
+// reportError logs the error, records it in the module status and calls error callbacks.
+// It must be called without m.mutex held.
+func (m *Module) reportError(err error) {
+	m.logError("onlineconf: reload error", err)
+
+	m.mutex.Lock()
+	m.lastError, m.lastErrorAt = err, time.Now()
+	callbacks := m.errorCallbacks
+	m.mutex.Unlock()
+
+	for _, cb := range callbacks {
+		cb(err)
+	}
+
+	callErrorCallbacks(m, err)
+}
+
+// callErrorCallbacks calls the callbacks registered by the package-level OnError.
+func callErrorCallbacks(m *Module, err error) {
+	errorCallbacks.Lock()
+	fns := errorCallbacks.fns
+	errorCallbacks.Unlock()
+
+	for _, fn := range fns {
+		fn(m, err)
+	}
+}
@@ -0,0 +1,67 @@
+package onlineconf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/key": "old"})
+
+	fileInfo, err := os.Stat(cdbName)
+	require.NoError(t, err)
+
+	status := mod.Status()
+	assert.Equal(t, uint64(1), status.Generation)
+	assert.False(t, status.LoadedAt.IsZero())
+	assert.Equal(t, fileInfo.Size(), status.Size)
+	assert.True(t, fileInfo.ModTime().Equal(status.ModTime))
+	assert.NoError(t, status.LastError)
+	assert.False(t, status.Closed)
+
+	moduleErrs := make(chan error, 2) // the watcher may fail to reload the file too
+	mod.OnError(func(err error) {
+		select {
+		case moduleErrs <- err:
+		default:
+		}
+	})
+
+	globalErrs := make(chan error, 2)
+	OnError(func(m *Module, err error) {
+		if m != mod {
+			return
+		}
+
+		select {
+		case globalErrs <- err:
+		default:
+		}
+	})
+
+	tmpName := cdbName + ".tmp"
+	require.NoError(t, os.WriteFile(tmpName, []byte("broken"), 0o644))
+	require.NoError(t, os.Rename(tmpName, cdbName))
+
+	err = mod.Reload()
+	require.Error(t, err)
+	assert.Error(t, <-moduleErrs)
+	assert.Error(t, <-globalErrs)
+
+	status = mod.Status()
+	assert.Equal(t, uint64(1), status.Generation, "a broken file must not be loaded")
+	assert.Equal(t, err, status.LastError)
+	assert.True(t, status.LastErrorAt.After(status.LoadedAt), "the module must be reported stuck")
+	assert.Equal(t, "old", mod.GetString("/key", ""))
+
+	writeCDB(t, cdbName, map[string]string{"/key": "new"})
+	require.NoError(t, mod.Reload())
+
+	status = mod.Status()
+	assert.Equal(t, uint64(2), status.Generation)
+	assert.True(t, status.LoadedAt.After(status.LastErrorAt), "the module must be reported recovered")
+	assert.Equal(t, "new", mod.GetString("/key", ""))
+}
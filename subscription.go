@@ -2,9 +2,9 @@ package onlineconf
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"hash"
-	"log"
 
 	"golang.org/x/crypto/blake2b"
 )
@@ -38,6 +38,11 @@ func (m *Module) subscribeChan(path string, isRecursive bool, ch chan<- struct{}
 
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	defer m.countSubscriptions()
+
+	if m.closed {
+		return ErrClosed
+	}
 
 	sub, ok := m.subscriptions[key]
 	if !ok {
@@ -140,6 +145,7 @@ func (m *Module) unsubscribeChan(path string, isRecursive bool, ch chan<- struct
 
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	defer m.countSubscriptions()
 
 	sub, ok := m.subscriptions[key]
 	if !ok {
@@ -180,6 +186,7 @@ func (m *Module) unsubscribe(path string, isRecursive bool) map[chan<- struct{}]
 
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	defer m.countSubscriptions()
 
 	sub, ok := m.subscriptions[key]
 	if !ok {
@@ -191,8 +198,11 @@ func (m *Module) unsubscribe(path string, isRecursive bool) map[chan<- struct{}]
 	return sub.channels
 }
 
-func (m *Module) processSubscriptions() {
+// processSubscriptions returns errors of all the subscriptions joined. Processing is continued after errors.
+func (m *Module) processSubscriptions() error {
 	// m.mutex is already write-locked since we are in reopen()
+	var errs []error
+
 	for key, sub := range m.subscriptions {
 		var (
 			current  []byte
@@ -206,9 +216,8 @@ func (m *Module) processSubscriptions() {
 			current, isHashed, err = m.getSubscr(key)
 			if err != nil {
 				// may not happen during the initial module open because m.subscriptions is empty yet.
-				// just log it and continue processing.
-				// TODO CDB may be seriously broken, is it worth continuing?
-				log.Print(err)
+				// report it and continue processing, other values may still be readable.
+				errs = append(errs, err)
 				continue
 			}
 
@@ -218,8 +227,11 @@ func (m *Module) processSubscriptions() {
 		}
 
 		for ch := range sub.channels {
-			if !notify(ch) {
+			isNotified, isDropped := notify(ch, struct{}{})
+			if !isNotified {
 				delete(sub.channels, ch)
+			} else if isDropped {
+				m.countDropped()
 			}
 		}
 
@@ -231,6 +243,8 @@ func (m *Module) processSubscriptions() {
 			m.subscriptions[key] = sub
 		}
 	}
+
+	return errors.Join(errs...)
 }
 
 // getSubscr returns raw value bytes (including the type byte) or it's blake2b-256 hash
@@ -298,7 +312,7 @@ func (m *Module) getRecursive(path string, h hash.Hash) error {
 }
 
 // safeClose closes the channel or does nothing if it's already closed.
-func safeClose(ch chan<- struct{}) {
+func safeClose[T any](ch chan<- T) {
 	defer func() {
 		_ = recover()
 	}()
@@ -306,17 +320,20 @@ func safeClose(ch chan<- struct{}) {
 	close(ch)
 }
 
-// notify returns false if the channel is closed,
+// notify sends the value to the channel without blocking. It returns false if the channel is closed,
 // or true if the notification is sent or the channel is busy.
-func notify(ch chan<- struct{}) (isNotified bool) {
+// isDropped is true if the channel is busy.
+func notify[T any](ch chan<- T, v T) (isNotified, isDropped bool) {
 	defer func() {
-		isNotified = recover() == nil
+		if recover() != nil {
+			isNotified, isDropped = false, false
+		}
 	}()
 
 	select {
-	case ch <- struct{}{}:
+	case ch <- v:
+		return true, false
 	default: // the channel is busy - there are pending notification(s) so it's surely "isNotified"
+		return true, true
 	}
-
-	return true
 }
@@ -1,16 +1,14 @@
 package onlineconf
 
 import (
-	"bytes"
-	"encoding/json"
 	"os"
-	"path"
-	"slices"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
-	"github.com/colinmarc/cdb"
+	"github.com/onlineconf/onlineconf-go/v2/internal/cdbtree"
 )
 
 func getTmpFname(t *testing.T, pattern string) string {
@@ -25,71 +23,45 @@ func getTmpFname(t *testing.T, pattern string) string {
 }
 
 func writeCDB(t *testing.T, fname string, tree map[string]string) {
-	tmpFname := getTmpFname(t, "test_*.cdb.tmp")
-
-	w, err := cdb.Create(tmpFname)
+	records, err := cdbtree.Flatten(tree)
 	if err != nil {
-		t.Fatalf("cdb.Create(%s): %v", tmpFname, err)
+		t.Fatalf("cdbtree.Flatten(%v): %v", tree, err)
 	}
 
-	childLists := map[string]map[string]struct{}{}
-
-	for key, val := range tree {
-		if err = w.Put(s2b(key), s2b("s"+val)); err != nil {
-			t.Fatalf("cdb.Put(%q, %q): %v", key, val, err)
-		}
-
-		for dir := key; dir != "/"; {
-			item := path.Base(dir)
-			dir = path.Dir(dir)
+	tmpFname := getTmpFname(t, "test_*.cdb.tmp")
 
-			set, ok := childLists[dir]
-			if !ok {
-				childLists[dir] = map[string]struct{}{
-					item: struct{}{},
-				}
-			} else {
-				set[item] = struct{}{}
-			}
-		}
+	f, err := os.Create(tmpFname)
+	if err != nil {
+		t.Fatalf("os.Create(%s): %v", tmpFname, err)
 	}
 
-	buf := &bytes.Buffer{}
-
-	for dir, children := range childLists {
-		buf.Reset()
-		_, _ = buf.Write([]byte{'j'})
-
-		list := make([]string, 0, len(children))
-		for key := range children {
-			list = append(list, key)
-		}
-
-		slices.Sort(list)
+	if err = cdbtree.Write(f, records); err != nil {
+		t.Fatalf("cdbtree.Write(%s): %v", tmpFname, err)
+	}
 
-		if err = json.NewEncoder(buf).Encode(&list); err != nil {
-			t.Fatalf("error encoding %s:%v: %v", dir, children, err)
-		}
+	if err = os.Rename(tmpFname, fname); err != nil {
+		t.Fatalf("os.Rename(%q, %q): %v", tmpFname, fname, err)
+	}
+}
 
-		buf.Truncate(buf.Len() - 1) // remove trailing linefeed
+// newTestModule writes the tree to a module file named after the test and opens it.
+// The module is closed when the test completes.
+func newTestModule(t *testing.T, tree map[string]string) (*Module, string) {
+	t.Helper()
 
-		listPath := "/"
-		if dir != "/" {
-			listPath = dir + "/"
-		}
+	fname := filepath.Join(t.TempDir(), strings.ReplaceAll(t.Name(), "/", "_")+".cdb")
+	writeCDB(t, fname, tree)
 
-		if err = w.Put(s2b(listPath), buf.Bytes()); err != nil {
-			t.Fatalf("cdb.Put(%q, %v): %v", listPath, children, err)
-		}
+	mod, err := OpenModule(fname)
+	if err != nil {
+		t.Fatalf("OpenModule(%q): %v", fname, err)
 	}
 
-	if err = w.Close(); err != nil {
-		t.Fatal("cdb.Close():", err)
-	}
+	t.Cleanup(func() {
+		_ = mod.Close()
+	})
 
-	if err = os.Rename(tmpFname, fname); err != nil {
-		t.Fatalf("os.Rename(%q, %q): %v", tmpFname, fname, err)
-	}
+	return mod, fname
 }
 
 func waitChan(t *testing.T, key string, ch <-chan struct{}) {
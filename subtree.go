@@ -105,6 +105,56 @@ func (s *Subtree) GetFloat(path string, dfl float64) float64 {
 	return s.mod.GetFloat(s.prefix+path, dfl)
 }
 
+// GetInt64Err calls [Module.GetInt64Err] using the subtree prefix.
+func (s *Subtree) GetInt64Err(path string) (int64, error) {
+	return s.mod.GetInt64Err(s.prefix + path)
+}
+
+// GetInt64IfExists calls [Module.GetInt64IfExists] using the subtree prefix.
+func (s *Subtree) GetInt64IfExists(path string) (int64, bool) {
+	return s.mod.GetInt64IfExists(s.prefix + path)
+}
+
+// GetInt64 calls [Module.GetInt64] using the subtree prefix.
+func (s *Subtree) GetInt64(path string, dfl int64) int64 {
+	return s.mod.GetInt64(s.prefix+path, dfl)
+}
+
+// GetUint64Err calls [Module.GetUint64Err] using the subtree prefix.
+func (s *Subtree) GetUint64Err(path string) (uint64, error) {
+	return s.mod.GetUint64Err(s.prefix + path)
+}
+
+// GetUint64IfExists calls [Module.GetUint64IfExists] using the subtree prefix.
+func (s *Subtree) GetUint64IfExists(path string) (uint64, bool) {
+	return s.mod.GetUint64IfExists(s.prefix + path)
+}
+
+// GetUint64 calls [Module.GetUint64] using the subtree prefix.
+func (s *Subtree) GetUint64(path string, dfl uint64) uint64 {
+	return s.mod.GetUint64(s.prefix+path, dfl)
+}
+
+// GetByteSizeErr calls [Module.GetByteSizeErr] using the subtree prefix.
+func (s *Subtree) GetByteSizeErr(path string) (uint64, error) {
+	return s.mod.GetByteSizeErr(s.prefix + path)
+}
+
+// GetByteSizeIfExists calls [Module.GetByteSizeIfExists] using the subtree prefix.
+func (s *Subtree) GetByteSizeIfExists(path string) (uint64, bool) {
+	return s.mod.GetByteSizeIfExists(s.prefix + path)
+}
+
+// GetByteSize calls [Module.GetByteSize] using the subtree prefix.
+func (s *Subtree) GetByteSize(path string, dfl uint64) uint64 {
+	return s.mod.GetByteSize(s.prefix+path, dfl)
+}
+
+// GetStringsErr calls [Module.GetStringsErr] using the subtree prefix.
+func (s *Subtree) GetStringsErr(path string, dfl []string) ([]string, error) {
+	return s.mod.GetStringsErr(s.prefix+path, dfl)
+}
+
 // GetStrings calls [Module.GetStrings] using the subtree prefix.
 func (s *Subtree) GetStrings(path string, dfl []string) []string {
 	return s.mod.GetStrings(s.prefix+path, dfl)
@@ -155,6 +205,36 @@ func (s *Subtree) UnsubscribeSubtree(path string) {
 	s.mod.UnsubscribeSubtree(s.prefix + path)
 }
 
+// SubscribeEventsChan calls [Module.SubscribeEventsChan] using the subtree prefix.
+func (s *Subtree) SubscribeEventsChan(path string, ch chan<- Event) error {
+	return s.mod.SubscribeEventsChan(s.prefix+path, ch)
+}
+
+// SubscribeEvents calls [Module.SubscribeEvents] using the subtree prefix.
+func (s *Subtree) SubscribeEvents(path string) (chan Event, error) {
+	return s.mod.SubscribeEvents(s.prefix + path)
+}
+
+// SubscribeEventsChanSubtree calls [Module.SubscribeEventsChanSubtree] using the subtree prefix.
+func (s *Subtree) SubscribeEventsChanSubtree(path string, ch chan<- Event) error {
+	return s.mod.SubscribeEventsChanSubtree(s.prefix+path, ch)
+}
+
+// SubscribeEventsSubtree calls [Module.SubscribeEventsSubtree] using the subtree prefix.
+func (s *Subtree) SubscribeEventsSubtree(path string) (chan Event, error) {
+	return s.mod.SubscribeEventsSubtree(s.prefix + path)
+}
+
+// UnsubscribeEventsChan calls [Module.UnsubscribeEventsChan] using the subtree prefix.
+func (s *Subtree) UnsubscribeEventsChan(path string, ch chan<- Event) {
+	s.mod.UnsubscribeEventsChan(s.prefix+path, ch)
+}
+
+// UnsubscribeEventsChanSubtree calls [Module.UnsubscribeEventsChanSubtree] using the subtree prefix.
+func (s *Subtree) UnsubscribeEventsChanSubtree(path string, ch chan<- Event) {
+	s.mod.UnsubscribeEventsChanSubtree(s.prefix+path, ch)
+}
+
 // Subtree returns a subtree of a subtree. Prefixes are concatenated using [path.Join].
 func (s *Subtree) Subtree(prefix string) *Subtree {
 	return &Subtree{
@@ -0,0 +1,72 @@
+package onlineconf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRejected is returned by [Module.Reload] and passed to [Module.OnReject] callbacks
+// if a new version of the module file is rejected by a validator.
+var ErrRejected = errors.New("onlineconf: module file rejected")
+
+// AddValidator registers a function validating every new version of the module file before it's installed.
+//
+// Validators are called from the goroutine reloading the module with a [Snapshot] of the new version,
+// which is released after they return and must not be retained. If any of them returns an error,
+// the new version is discarded: the module keeps serving the current version, subscribers aren't notified,
+// and [Module.OnReject] callbacks are called. The next replacement of the file is loaded and validated as usual.
+//
+// The version loaded by [OpenModule] isn't validated, since validators are registered after it's loaded.
+// Validators are useless for modules created by NewModule*, as they are never reloaded.
+func (m *Module) AddValidator(fn func(snap *Snapshot) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.validators = append(m.validators, fn)
+}
+
+// OnReject registers a callback called with an error wrapping [ErrRejected] and validator errors
+// every time a new version of the module file is rejected by validators (see [Module.AddValidator]).
+//
+// Callbacks are called sequentially from the goroutine reloading the module.
+func (m *Module) OnReject(fn func(err error)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.rejectCallbacks = append(m.rejectCallbacks, fn)
+}
+
+// validate runs all the validators against the generation and returns their errors joined.
+func (m *Module) validate(gen *generation, validators []func(*Snapshot) error) error {
+	if len(validators) == 0 {
+		return nil
+	}
+
+	gen.tryAcquire() // owned by the caller, so it never fails
+	snap := m.snapshot(gen)
+	defer snap.Release()
+
+	var errs []error
+
+	for _, validate := range validators {
+		if err := validate(snap); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w: %w", m.filename, ErrRejected, errors.Join(errs...))
+}
+
+func (m *Module) reject(err error) {
+	m.mutex.Lock()
+	callbacks := m.rejectCallbacks
+	m.mutex.Unlock()
+
+	for _, cb := range callbacks {
+		cb(err)
+	}
+}
@@ -0,0 +1,59 @@
+package onlineconf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidators(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/db/host": "old.local", "/db/port": "5432"})
+
+	errBadPort := errors.New("bad port")
+
+	mod.AddValidator(func(snap *Snapshot) error {
+		assert.Equal(t, "old.local", mod.GetString("/db/host", ""), "validators must be called before the new file is installed")
+
+		if port := snap.GetInt("/db/port", 0); port <= 0 {
+			return errBadPort
+		}
+
+		return nil
+	})
+	mod.AddValidator(func(snap *Snapshot) error {
+		_, err := snap.GetStringErr("/db/host")
+		return err
+	})
+
+	rejected := make(chan error, 2) // the watcher may reject the file too
+	mod.OnReject(func(err error) {
+		select {
+		case rejected <- err:
+		default:
+		}
+	})
+
+	ch, err := mod.Subscribe("/db/port")
+	require.NoError(t, err)
+
+	writeCDB(t, cdbName, map[string]string{"/db/port": "x"})
+
+	err = mod.Reload()
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.ErrorIs(t, err, errBadPort)
+	assert.ErrorIs(t, err, ErrNotFound, "errors of all the validators must be reported")
+	assert.ErrorIs(t, <-rejected, ErrRejected)
+
+	assert.Equal(t, "old.local", mod.GetString("/db/host", ""), "the old file must stay active")
+	assert.Equal(t, 5432, mod.GetInt("/db/port", 0))
+	assert.Empty(t, ch, "subscribers must not be notified about a rejected file")
+
+	writeCDB(t, cdbName, map[string]string{"/db/host": "new.local", "/db/port": "6432"})
+	require.NoError(t, mod.Reload())
+
+	assert.Equal(t, "new.local", mod.GetString("/db/host", ""), "the next good file must be loaded")
+	assert.Equal(t, 6432, mod.GetInt("/db/port", 0))
+	waitChan(t, "/db/port", ch)
+}
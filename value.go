@@ -0,0 +1,23 @@
+package onlineconf
+
+// Format is a type of a raw parameter value stored in a CDB file.
+type Format byte
+
+// Value formats
+const (
+	FormatText Format = 's' // any text value including numbers, strings, and bools (since onlineconf UI doesn't support strict typing)
+	FormatJSON Format = 'j' // JSON or YAML (which is converted to JSON in the updater)
+)
+
+// Value is a raw parameter value.
+//
+// Data may be shared with caches and other users of the value, so it must not be modified.
+type Value struct {
+	Format Format
+	Data   []byte
+}
+
+// String returns Data as a string.
+func (v Value) String() string {
+	return string(v.Data)
+}
@@ -0,0 +1,162 @@
+package onlineconf
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// SkipSubtree is used as a return value from a [WalkFunc] to indicate that descendants of the path
+// passed to the function are to be skipped. It isn't returned as an error by any function.
+var SkipSubtree = errors.New("skip this subtree") //nolint:revive,stylecheck
+
+// WalkFunc is a function called by [Module.Walk] and [Subtree.Walk] for every existing value.
+// If the function returns a non-nil error (other than [SkipSubtree]), Walk stops and returns the error.
+type WalkFunc func(path string, value Value) error
+
+type record struct {
+	path  string
+	value Value
+}
+
+// All returns an iterator over all parameters of the module in the order they are stored in the file.
+// Child lists (keys ending with a slash) aren't included.
+//
+// All the parameters are read before the iteration is started, so reloads of the module
+// don't affect the iteration. Read errors are logged, nothing is yielded in this case.
+func (m *Module) All() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		records, err := m.records("")
+		if err != nil {
+			m.logError("onlineconf: iteration failed", err)
+			return
+		}
+
+		for _, rec := range records {
+			if !yield(rec.path, rec.value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over all parameters of the subtree. Paths yielded are relative
+// to the subtree prefix (so they can be passed to Get* methods of the subtree), the value of
+// the prefix itself has an empty path. See [Module.All] for other details.
+func (s *Subtree) All() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		records, err := s.mod.records(s.prefix)
+		if err != nil {
+			s.mod.logError("onlineconf: iteration failed", err)
+			return
+		}
+
+		for _, rec := range records {
+			if !yield(strings.TrimPrefix(rec.path, s.prefix), rec.value) {
+				return
+			}
+		}
+	}
+}
+
+// records reads all the parameters with the prefix (a path of a subtree), or all the parameters if it's empty.
+func (m *Module) records(prefix string) ([]record, error) {
+	gen, shard := m.pin()
+	if gen == nil {
+		return nil, ErrClosed
+	}
+
+	defer m.unpin(gen, shard)
+
+	var records []record
+
+	iter := gen.cdb.Iter()
+	for iter.Next() {
+		path := b2s(iter.Key())
+		if isChildListKey(path) || !isInSubtree(path, prefix) {
+			continue
+		}
+
+		records = append(records, record{path: path, value: rawToValue(iter.Value())})
+	}
+
+	if err := iter.Err(); err != nil {
+		return records, fmt.Errorf("%s: cdb.Iterator.Next: %w", m.location(), err)
+	}
+
+	return records, nil
+}
+
+func isInSubtree(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix) && path[len(prefix)] == '/'
+}
+
+// Walk walks the subtree rooted at the path using child lists, calling fn for every existing value
+// including the value of the path itself. Children are visited in the order of their child lists,
+// every parent is visited before its children.
+//
+// The whole walk reads the version of the module file loaded when it's started, so reloads of the module
+// don't affect it.
+//
+// Path separators other than a slash aren't supported by this method.
+// `child_lists` OnlineConf feature is required, [ErrNoChildLists] is returned if the module has no child lists.
+func (m *Module) Walk(path string, fn WalkFunc) error {
+	return m.walkPinned(cleanPath(path), fn)
+}
+
+// Walk calls [Module.Walk] using the subtree prefix. Paths passed to fn are relative to the subtree prefix.
+func (s *Subtree) Walk(path string, fn WalkFunc) error {
+	return s.mod.walkPinned(cleanPath(s.prefix+path), func(path string, value Value) error {
+		return fn(strings.TrimPrefix(path, s.prefix), value)
+	})
+}
+
+// walkPinned walks the current generation pinned for the whole walk.
+func (m *Module) walkPinned(path string, fn WalkFunc) error {
+	gen, shard := m.pin()
+	if gen == nil {
+		return ErrClosed
+	}
+
+	defer m.unpin(gen, shard)
+
+	if err := m.hasChildLists(gen); err != nil {
+		return err
+	}
+
+	err := m.walk(gen, path, fn)
+	if err == SkipSubtree {
+		return nil
+	}
+
+	return err
+}
+
+func (m *Module) walk(gen *generation, path string, fn WalkFunc) error {
+	if path != "/" { // the root "value" is a child list
+		data, err := m.genRaw(gen, path)
+		if err != nil {
+			return err
+		}
+
+		if len(data) != 0 {
+			if err := fn(path, rawToValue(data)); err != nil {
+				return err
+			}
+		}
+	}
+
+	children, err := m.genStringsRaw(gen, childListPath(path))
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := m.walk(gen, joinChildPath(path, child), fn); err != nil && err != SkipSubtree {
+			return err
+		}
+	}
+
+	return nil
+}
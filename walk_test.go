@@ -0,0 +1,116 @@
+package onlineconf
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	tree := map[string]string{
+		"/a":      "1",
+		"/b":      "2",
+		"/b/c":    "3",
+		"/b/d/e":  "4",
+		"/bb":     "5",
+		"/x/y/zz": "6",
+	}
+
+	mod, err := NewModuleFromMap("test", tree)
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	got := map[string]string{}
+	for path, val := range mod.All() {
+		got[path] = val.String()
+	}
+
+	assert.Equal(t, tree, got)
+
+	got = map[string]string{}
+	for path, val := range mod.Subtree("/b").All() {
+		got[path] = val.String()
+	}
+
+	assert.Equal(t, map[string]string{"": "2", "/c": "3", "/d/e": "4"}, got)
+
+	n := 0
+	for range mod.All() {
+		n++
+		break
+	}
+
+	assert.Equal(t, 1, n)
+}
+
+func TestWalk(t *testing.T) {
+	mod, err := NewModuleFromMap("test", map[string]string{
+		"/a":     "1",
+		"/b":     "2",
+		"/b/c":   "3",
+		"/b/d/e": "4",
+		"/bb":    "5",
+	})
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	var paths []string
+
+	err = mod.Walk("/", func(path string, val Value) error {
+		paths = append(paths, path+"="+val.String())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a=1", "/b=2", "/b/c=3", "/b/d/e=4", "/bb=5"}, paths)
+
+	paths = nil
+	err = mod.Walk("/", func(path string, _ Value) error {
+		paths = append(paths, path)
+		if path == "/b" {
+			return SkipSubtree
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a", "/b", "/bb"}, paths)
+
+	got := map[string]string{}
+	err = mod.Subtree("/b").Walk("", func(path string, val Value) error {
+		got[path] = val.String()
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"": "2", "/c": "3", "/d/e": "4"}, got)
+
+	stop := assert.AnError
+	err = mod.Walk("/b", func(string, Value) error { return stop })
+	assert.Equal(t, stop, err)
+
+	all := maps.Collect(mod.All())
+	assert.Len(t, all, 5)
+}
+
+func TestWalkReload(t *testing.T) {
+	mod, cdbName := newTestModule(t, map[string]string{"/a": "old", "/b": "old", "/b/c": "old"})
+
+	var paths []string
+
+	err := mod.Walk("/", func(path string, val Value) error {
+		if path == "/a" {
+			writeCDB(t, cdbName, map[string]string{"/a": "new", "/b/d": "new"})
+			require.NoError(t, mod.Reload())
+		}
+
+		paths = append(paths, path+"="+val.String())
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a=old", "/b=old", "/b/c=old"}, paths, "the walk must read a single version")
+	assert.Equal(t, "new", mod.GetString("/a", ""))
+}
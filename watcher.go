@@ -1,73 +1,407 @@
 package onlineconf
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
 const tracebackMaxSize = 65536
 
+// writeQuietPeriod is the time without writes to a file after which a file written in place is reloaded.
+// Every write produces an event, and reloading a partially written file only fails.
+var writeQuietPeriod = 100 * time.Millisecond
+
+// Watcher is a strategy of tracking module files for changes.
+//
+// The default strategy is [NewFSNotifyWatcher]. [NewPollingWatcher] can be used on file systems
+// not supporting inotify events, e.g. NFS or some bind mounts into containers.
+// The strategy is selected globally using [SetDefaultWatcher] or per module using [Module.SetWatcher].
+type Watcher interface {
+	// Watch starts tracking the file and calls changed every time the file may have been replaced or modified.
+	// Spurious calls are harmless, since modules aren't reloaded if the file isn't changed.
+	// changed is called from a goroutine of the watcher and blocks it until the module is reloaded.
+	// The function returned stops tracking.
+	Watch(filename string, changed func()) (stop func(), err error)
+}
+
+var defaultWatcher atomic.Pointer[Watcher]
+
+// SetDefaultWatcher sets the watcher used by modules opened after the call.
+// Passing nil restores the default, [NewFSNotifyWatcher].
+func SetDefaultWatcher(w Watcher) {
+	if w == nil {
+		defaultWatcher.Store(nil)
+		return
+	}
+
+	defaultWatcher.Store(&w)
+}
+
+func getDefaultWatcher() Watcher {
+	if w := defaultWatcher.Load(); w != nil {
+		return *w
+	}
+
+	return fsnotifyStrategy{}
+}
+
+// NewFSNotifyWatcher returns a [Watcher] tracking module files using the [fsnotify] library.
+// Directories containing module files are watched for file creation, so a module file should be replaced
+// atomically by renaming a new file over it. Writes to a file in place are detected too (see [LoadMode]),
+// the file is reloaded when it isn't written for a short period. If the path contains symlinks,
+// the directory of the symlink target is watched too, and a replacement of a symlink in the directory
+// of the file (e.g. the "..data" symlink of a Kubernetes ConfigMap volume) is detected.
+// All the fsnotify watchers share the same inotify instance.
+func NewFSNotifyWatcher() Watcher {
+	return fsnotifyStrategy{}
+}
+
+type fsnotifyStrategy struct{}
+
+func (fsnotifyStrategy) Watch(filename string, changed func()) (func(), error) {
+	w, err := initWatcherOnce()
+	if err != nil {
+		return nil, err
+	}
+
+	return w.watch(filename, changed)
+}
+
+// fileWatch is a registration of a changed callback, a pointer is used as a unique key.
+type fileWatch struct {
+	path       string // the file path as passed to Watch
+	target     string // the path with symlinks resolved during the last check
+	changed    func()
+	writeTimer *time.Timer // calls changed after writeQuietPeriod since the last write, nil if there were no writes
+}
+
+// watcher counts modules opened in every watched directory
+// to stop watching a directory when the last module in it is closed.
+//
+// Both the directory of a file path and the directory of its symlink target are watched.
+// The target is resolved again on every creation event in the directory of the path,
+// so atomic swaps of symlinks (e.g. the "..data" symlink of Kubernetes ConfigMap volumes) are detected.
+type watcher struct {
+	*fsnotify.Watcher
+	mutex sync.Mutex
+	dirs  map[string]int
+	files map[string]map[*fileWatch]struct{} // by paths and by targets
+}
+
 var initWatcherOnce = sync.OnceValues(initWatcherOnceFunc)
 
-func initWatcherOnceFunc() (*fsnotify.Watcher, error) {
-	watcher, err := fsnotify.NewWatcher()
+func initWatcherOnceFunc() (*watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("fsnotify.NewWatcher: %w", err)
 	}
 
+	w := &watcher{
+		Watcher: fsWatcher,
+		dirs:    make(map[string]int),
+		files:   make(map[string]map[*fileWatch]struct{}),
+	}
+
 	go func() {
 		for {
 			func() {
-				defer func() {
-					if reason := recover(); reason != nil {
-						log.Printf("watcher panic: %v\n%s\n", reason, traceback())
-					}
-				}()
+				defer recoverWatcherPanic()
 
 				select {
-				case ev := <-watcher.Events:
-					// log.Println("fsnotify event:", ev)
-					if ev.Op&fsnotify.Create == fsnotify.Create {
-						module, ok := modCache.loadOnly(ev.Name) // paths are always absolute
-						if !ok {
-							break
-						}
-
-						if err := module.reopen(); err != nil {
-							log.Printf("watch %s: reopen failed: %v", ev.Name, err)
+				case ev := <-fsWatcher.Events:
+					// logger().Debug("onlineconf: fsnotify event", "event", ev)
+					switch {
+					case ev.Has(fsnotify.Create):
+						for _, fw := range w.changedWatches(ev.Name) { // paths are always absolute
+							callChanged(fw.changed)
 						}
+					case ev.Has(fsnotify.Write): // the file is written in place, it's reloaded when writes stop
+						w.delayChanged(ev.Name)
 					}
 
-				case err := <-watcher.Errors:
-					log.Print("Watch error: ", err)
+				case err := <-fsWatcher.Errors:
+					logger().Error("onlineconf: watch error", "error", err)
+					callErrorCallbacks(nil, fmt.Errorf("onlineconf: fsnotify: %w", err))
 				}
 			}()
 		}
 	}()
 
-	return watcher, nil
+	return w, nil
+}
+
+// recoverWatcherPanic is deferred by watcher goroutines to survive panics.
+func recoverWatcherPanic() {
+	if reason := recover(); reason != nil {
+		logger().Error("onlineconf: watcher panic", "panic", reason, "traceback", traceback())
+		callErrorCallbacks(nil, fmt.Errorf("onlineconf: watcher panic: %v", reason))
+	}
 }
 
-func initWatcher(dir string) error {
-	watcher, err := initWatcherOnce()
+// callChanged calls a changed callback of a watcher surviving its panics.
+func callChanged(changed func()) {
+	defer recoverWatcherPanic()
+
+	changed()
+}
+
+// changedWatches returns watches of the file created: watches of the path or the target equal to the name,
+// and watches of paths in the same directory, which symlink targets are changed.
+func (w *watcher) changedWatches(name string) []*fileWatch {
+	var (
+		watches    []*fileWatch
+		candidates []*fileWatch // watches of other paths in the directory
+	)
+
+	dir := filepath.Dir(name)
+
+	w.mutex.Lock()
+
+	for fw := range w.files[name] {
+		watches = append(watches, fw)
+	}
+
+	for _, byName := range w.files {
+		for fw := range byName {
+			if filepath.Dir(fw.path) == dir && !slices.Contains(watches, fw) && !slices.Contains(candidates, fw) {
+				candidates = append(candidates, fw)
+			}
+		}
+	}
+
+	w.mutex.Unlock()
+
+	if len(candidates) == 0 {
+		return watches
+	}
+
+	targets := make([]string, len(candidates))
+	for i, fw := range candidates { // symlinks are resolved without the lock, it may take a while
+		targets[i] = resolveTarget(fw.path)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for i, fw := range candidates {
+		if _, ok := w.files[fw.path][fw]; !ok { // stopped concurrently
+			continue
+		}
+
+		if targets[i] != fw.target {
+			w.retarget(fw, targets[i])
+			watches = append(watches, fw)
+		}
+	}
+
+	return watches
+}
+
+// delayChanged (re)starts write timers of watches of the path or the target equal to the name.
+func (w *watcher) delayChanged(name string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for fw := range w.files[name] {
+		if fw.writeTimer == nil {
+			fw.writeTimer = time.AfterFunc(writeQuietPeriod, func() { callChanged(fw.changed) })
+		} else {
+			fw.writeTimer.Reset(writeQuietPeriod)
+		}
+	}
+}
+
+// resolveTarget returns the path with symlinks resolved, or the path itself if it can't be resolved,
+// e.g. while a symlink is being swapped.
+func resolveTarget(path string) string {
+	target, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return err
+		return path
+	}
+
+	return target
+}
+
+func (w *watcher) watch(path string, changed func()) (func(), error) {
+	fw := &fileWatch{
+		path:    path,
+		target:  resolveTarget(path),
+		changed: changed,
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.addDir(filepath.Dir(fw.path)); err != nil {
+		return nil, err
+	}
+
+	w.index(fw.path, fw)
+
+	if fw.target != fw.path {
+		if err := w.addDir(filepath.Dir(fw.target)); err != nil {
+			w.unindex(fw.path, fw)
+			w.removeDir(filepath.Dir(fw.path))
+
+			return nil, err
+		}
+
+		w.index(fw.target, fw)
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			w.mutex.Lock()
+			defer w.mutex.Unlock()
+
+			w.unindex(fw.path, fw)
+			w.removeDir(filepath.Dir(fw.path))
+
+			if fw.target != fw.path {
+				w.unindex(fw.target, fw)
+				w.removeDir(filepath.Dir(fw.target))
+			}
+
+			if fw.writeTimer != nil {
+				fw.writeTimer.Stop()
+			}
+		})
+	}, nil
+}
+
+// retarget updates the symlink target of the watch. The caller must hold w.mutex.
+func (w *watcher) retarget(fw *fileWatch, target string) {
+	if fw.target != fw.path {
+		w.unindex(fw.target, fw)
+		w.removeDir(filepath.Dir(fw.target))
+	}
+
+	fw.target = target
+
+	if fw.target != fw.path {
+		if err := w.addDir(filepath.Dir(fw.target)); err != nil {
+			logger().Error("onlineconf: watch failed", "dir", filepath.Dir(fw.target), "error", err)
+			fw.target = fw.path // resolved again on the next event
+
+			return
+		}
+
+		w.index(fw.target, fw)
+	}
+}
+
+// index adds the watch to w.files. The caller must hold w.mutex.
+func (w *watcher) index(name string, fw *fileWatch) {
+	if w.files[name] == nil {
+		w.files[name] = make(map[*fileWatch]struct{})
 	}
 
-	if err = watcher.Add(dir); err != nil { // watcher.Add is goro-safe
-		return fmt.Errorf("fsnotify.Watcher.Add: %w", err)
+	w.files[name][fw] = struct{}{}
+}
+
+// unindex removes the watch from w.files. The caller must hold w.mutex.
+func (w *watcher) unindex(name string, fw *fileWatch) {
+	delete(w.files[name], fw)
+
+	if len(w.files[name]) == 0 {
+		delete(w.files, name)
 	}
+}
+
+// addDir starts watching the directory or increments its reference counter if it's already watched.
+// The caller must hold w.mutex.
+func (w *watcher) addDir(dir string) error {
+	if w.dirs[dir] == 0 {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("fsnotify.Watcher.Add: %w", err)
+		}
+	}
+
+	w.dirs[dir]++
 
 	return nil
 }
 
+// removeDir decrements the directory reference counter and stops watching it when the counter drops to zero.
+// The caller must hold w.mutex.
+func (w *watcher) removeDir(dir string) {
+	switch w.dirs[dir] {
+	case 0:
+		return
+	case 1:
+		delete(w.dirs, dir)
+
+		// the directory may be already removed, e.g. an old target directory of a swapped symlink
+		if err := w.Remove(dir); err != nil && !errors.Is(err, fsnotify.ErrNonExistentWatch) {
+			logger().Error("onlineconf: unwatch failed", "dir", dir, "error", fmt.Errorf("fsnotify.Watcher.Remove(%s): %w", dir, err))
+		}
+	default:
+		w.dirs[dir]--
+	}
+}
+
 func traceback() string {
 	traceback := make([]byte, tracebackMaxSize)
 	size := runtime.Stack(traceback, false)
 
 	return b2s(traceback[:size])
 }
+
+// SetWatcher changes the strategy of tracking the module file and its checksum sidecar file
+// (see [ChecksumSuffix]) for changes, see [Watcher].
+// Modules returned by [OpenModule] are shared, so the change affects all their users.
+// SetWatcher does nothing for modules created by NewModule*.
+func (m *Module) SetWatcher(w Watcher) error {
+	if m.filename == "" {
+		return nil
+	}
+
+	changed := func() { _ = m.reopen() } // errors are reported by reopen
+
+	stopFile, err := w.Watch(m.filename, changed)
+	if err != nil {
+		return fmt.Errorf("%s: watch: %w", m.filename, err)
+	}
+
+	// the file is refused until its checksum sidecar is replaced if the latter is written last
+	stopSidecar, err := w.Watch(m.filename+ChecksumSuffix, changed)
+	if err != nil {
+		stopFile()
+		return fmt.Errorf("%s%s: watch: %w", m.filename, ChecksumSuffix, err)
+	}
+
+	stop := func() {
+		stopFile()
+		stopSidecar()
+	}
+
+	m.mutex.Lock()
+
+	if m.closed {
+		m.mutex.Unlock()
+		stop()
+
+		return ErrClosed
+	}
+
+	oldStop := m.stopWatch
+	m.stopWatch = stop
+
+	m.mutex.Unlock()
+
+	if oldStop != nil {
+		oldStop()
+	}
+
+	return nil
+}
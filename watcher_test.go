@@ -0,0 +1,63 @@
+package onlineconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatcherSymlinkSwap reproduces updates of Kubernetes ConfigMap volumes:
+// TREE.cdb -> ..data/TREE.cdb, ..data -> ..v1, and ..data is atomically replaced by a symlink to ..v2.
+func TestWatcherSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o755))
+	writeCDB(t, filepath.Join(dir, "..v1", "TREE.cdb"), map[string]string{"/key": "v1"})
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "TREE.cdb"), filepath.Join(dir, "TREE.cdb")))
+
+	mod, err := OpenModule(filepath.Join(dir, "TREE.cdb"))
+	require.NoError(t, err)
+
+	defer mod.Close()
+
+	assert.Equal(t, "TREE.cdb", mod.name)
+	assert.Equal(t, "v1", mod.GetString("/key", ""))
+
+	ch, err := mod.Subscribe("/key")
+	require.NoError(t, err)
+
+	for _, version := range []string{"v2", "v3"} {
+		versionDir := filepath.Join(dir, ".."+version)
+		require.NoError(t, os.Mkdir(versionDir, 0o755))
+		writeCDB(t, filepath.Join(versionDir, "TREE.cdb"), map[string]string{"/key": version})
+
+		oldTarget, err := os.Readlink(filepath.Join(dir, "..data"))
+		require.NoError(t, err)
+
+		require.NoError(t, os.Symlink(".."+version, filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, oldTarget)))
+
+		waitChan(t, "/key", ch)
+		assert.Equal(t, version, mod.GetString("/key", ""))
+	}
+
+	w, err := initWatcherOnce()
+	require.NoError(t, err)
+
+	w.mutex.Lock()
+	assert.Equal(t, 1, w.dirs[filepath.Join(dir, "..v3")], "the new target directory must be watched")
+	assert.NotContains(t, w.dirs, filepath.Join(dir, "..v1"), "the old target directory must not be watched")
+	w.mutex.Unlock()
+
+	require.NoError(t, mod.Close())
+
+	w.mutex.Lock()
+	assert.NotContains(t, w.dirs, dir)
+	assert.NotContains(t, w.dirs, filepath.Join(dir, "..v3"))
+	w.mutex.Unlock()
+}